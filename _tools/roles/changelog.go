@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+var changelog = flag.Bool("changelog", false, "compare roles of two SDK module directories given as arguments, followed by optional output files")
+
+// Changelog lists changes of roles between two SDK versions.
+type Changelog struct {
+	Old          string
+	New          string
+	Added        []RoleChange
+	Removed      []RoleChange
+	Renumbered   []RoleChange
+	Redocumented []RoleChange
+}
+
+// RoleChange describes a change of a single role. Fields that are not
+// relevant to the change are omitted.
+type RoleChange struct {
+	Name     string
+	OldValue *int   `json:",omitempty"`
+	NewValue *int   `json:",omitempty"`
+	OldDoc   string `json:",omitempty"`
+	NewDoc   string `json:",omitempty"`
+	// UsedBy is the list of languages still using the removed role.
+	UsedBy []string `json:",omitempty"`
+}
+
+// diffRoles compares two lists of roles by name. Changes of the doc comments
+// that only affect white space are ignored.
+func diffRoles(old, cur Roles) Changelog {
+	var cl Changelog
+	byName := make(map[string]*Role, len(old))
+	for _, r := range old {
+		byName[r.Name] = r
+	}
+
+	seen := make(map[string]bool, len(cur))
+	for _, r := range cur {
+		seen[r.Name] = true
+		newValue := r.Value
+		prev, ok := byName[r.Name]
+		if !ok {
+			cl.Added = append(cl.Added, RoleChange{Name: r.Name, NewValue: &newValue, NewDoc: r.Doc})
+			continue
+		}
+
+		oldValue := prev.Value
+		if oldValue != newValue {
+			cl.Renumbered = append(cl.Renumbered, RoleChange{Name: r.Name, OldValue: &oldValue, NewValue: &newValue})
+		}
+		if flattenDoc(prev.Doc) != flattenDoc(r.Doc) {
+			cl.Redocumented = append(cl.Redocumented, RoleChange{Name: r.Name, OldDoc: prev.Doc, NewDoc: r.Doc})
+		}
+	}
+
+	for _, r := range old {
+		if seen[r.Name] {
+			continue
+		}
+
+		oldValue := r.Value
+		cl.Removed = append(cl.Removed, RoleChange{Name: r.Name, OldValue: &oldValue, OldDoc: r.Doc})
+	}
+
+	return cl
+}
+
+// runChangelog compares roles of two SDK versions, checked out to the module
+// directories given as the first two arguments, and lists drivers still using
+// removed roles. The changelog is written to the rest of arguments, or printed
+// as Markdown if none are given.
+func runChangelog(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("expected directories of the old and the new SDK module, got %d arguments", len(args))
+	}
+
+	old, err := findRoles(args[0], "./uast/role")
+	if err != nil {
+		return err
+	}
+	cur, err := findRoles(args[1], "./uast/role")
+	if err != nil {
+		return err
+	}
+
+	cl := diffRoles(old, cur)
+	cl.Old, cl.New = args[0], args[1]
+
+	var last error
+	if len(cl.Removed) > 0 {
+		last = findRemovedUsage(cl.Removed)
+	}
+
+	if len(args) == 2 {
+		fmt.Print(formatChangelog(cl))
+		return last
+	}
+	for _, fname := range args[2:] {
+		if err := writeChangelogFile(fname, cl); err != nil {
+			return err
+		}
+	}
+	return last
+}
+
+// findRemovedUsage fills the list of languages using each of the removed roles,
+// either in the code or in the fixtures of the driver.
+func findRemovedUsage(removed []RoleChange) error {
+	if err := listDrivers(); err != nil {
+		return err
+	}
+
+	var roles Roles
+	for _, c := range removed {
+		roles = append(roles, &Role{
+			Name:      c.Name,
+			Languages: make(map[string][]token.Position),
+			Observed:  make(map[string]int),
+			Native:    make(map[string]map[string]int),
+			Examples:  make(map[string]*example),
+		})
+	}
+
+	var last error
+	for i, res := range loadAll(OfficialDriver) {
+		d := OfficialDriver[i]
+		if res.syncErr != nil {
+			log.Println(res.syncErr)
+			last = res.syncErr
+		}
+		if res.err != nil {
+			log.Println(res.err)
+			last = res.err
+		}
+		for _, u := range res.uses {
+			roles.UsedBy(u.Name, d.Language, u.Pos)
+		}
+		if err := findObserved(d, roles); err != nil {
+			// fixtures are optional
+			log.Println(err)
+		}
+	}
+
+	for i, role := range roles {
+		for _, d := range OfficialDriver {
+			if role.IsUsedBy(d.Language) || role.Observed[d.Language] > 0 {
+				removed[i].UsedBy = append(removed[i].UsedBy, d.Language)
+			}
+		}
+	}
+
+	return last
+}
+
+// flattenDoc joins lines of the doc comment, to fit into a list item.
+func flattenDoc(doc string) string {
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+// formatChangelog renders the changelog as Markdown.
+func formatChangelog(cl Changelog) string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "# Roles changelog\n\nChanges of roles from `%s` to `%s`.\n\n", cl.Old, cl.New)
+
+	writeSection := func(title string, l []RoleChange, format func(c RoleChange) string) {
+		fmt.Fprintf(buf, "## %s\n\n", title)
+		if len(l) == 0 {
+			fmt.Fprint(buf, "None.\n\n")
+			return
+		}
+
+		for _, c := range l {
+			fmt.Fprintf(buf, " - %s\n", format(c))
+		}
+
+		fmt.Fprint(buf, "\n")
+	}
+
+	writeSection("Added", cl.Added, func(c RoleChange) string {
+		return fmt.Sprintf("**%s** (%d): %s", c.Name, *c.NewValue, flattenDoc(c.NewDoc))
+	})
+	writeSection("Removed", cl.Removed, func(c RoleChange) string {
+		s := fmt.Sprintf("**%s** (%d)", c.Name, *c.OldValue)
+		if len(c.UsedBy) > 0 {
+			var l []string
+			for _, language := range c.UsedBy {
+				l = append(l, strings.Title(language))
+			}
+			s += ", still used by: " + strings.Join(l, ", ")
+		}
+		return s
+	})
+	writeSection("Renumbered", cl.Renumbered, func(c RoleChange) string {
+		return fmt.Sprintf("**%s**: %d → %d", c.Name, *c.OldValue, *c.NewValue)
+	})
+	writeSection("Documentation changed", cl.Redocumented, func(c RoleChange) string {
+		return fmt.Sprintf("**%s**: ~~%s~~ %s", c.Name, flattenDoc(c.OldDoc), flattenDoc(c.NewDoc))
+	})
+
+	return buf.String()
+}
+
+// writeChangelogFile writes the changelog to a file, the format is selected by
+// the file extension.
+func writeChangelogFile(fname string, cl Changelog) error {
+	const filePerm = 0644
+	switch filepath.Ext(fname) {
+	case ".json":
+		data, err := json.MarshalIndent(cl, "", "\t")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(fname, data, filePerm)
+	case ".md":
+		fallthrough
+	default:
+	}
+
+	return ioutil.WriteFile(fname, []byte(formatChangelog(cl)), filePerm)
+}
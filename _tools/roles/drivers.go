@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/driver/manifest/discovery"
+)
+
+const normalizerDir = "driver/normalizer"
+
+var (
+	driversDir = flag.String("drivers", filepath.Join(".", "_drivers"),
+		"directory to clone drivers to, shared with the types tool")
+	skipUpdate = flag.Bool("skip", false, "skip git clone or pull")
+	mirror     = flag.String("mirror", "", "base URL of a mirror to clone drivers from, instead of GitHub")
+)
+
+// repoPath returns the path to the local clone of the driver.
+func repoPath(d discovery.Driver) string {
+	return filepath.Join(*driversDir, path.Base(d.RepositoryURL()))
+}
+
+// cloneURL returns the URL to clone the driver from, taking the mirror into
+// account.
+func cloneURL(d discovery.Driver) string {
+	if *mirror == "" {
+		return d.RepositoryURL() + ".git"
+	}
+
+	return strings.TrimSuffix(*mirror, "/") + "/" + path.Base(d.RepositoryURL()) + ".git"
+}
+
+// maybeCloneOrPull either clones the driver to the local FS or, if already
+// present, pulls the latest master.
+func maybeCloneOrPull(d discovery.Driver) error {
+	dir := repoPath(d)
+	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(*driversDir, os.ModePerm); err != nil {
+			return err
+		}
+
+		log.Printf("%s does not exist, cloning from %s", dir, cloneURL(d))
+		return git(*driversDir, "clone", cloneURL(d), filepath.Base(dir))
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("%s dir exists, will git pull instead", dir)
+	return git(dir, "pull", cloneURL(d), "master")
+}
+
+// git runs a git command in the given directory.
+func git(dir string, args ...string) error {
+	_, err := gitOutput(dir, args...)
+	return err
+}
+
+// gitOutput runs a git command in the given directory and returns its trimmed
+// output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	out = bytes.TrimSpace(out)
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+
+	return string(out), nil
+}
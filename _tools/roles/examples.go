@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// maxExampleLines max number of lines of source code shown for an example
+const maxExampleLines = 10
+
+// example is a node with a role found in driver fixtures.
+type example struct {
+	source     string // path to the source file of the fixture
+	start, end uint32 // offsets of the node in the source file
+}
+
+// newExample returns an example for the node, or nil if the node has no
+// valid positional information. Malformed positions are ignored, the fixture
+// is only used to find examples here.
+func newExample(obj nodes.Object, source string) *example {
+	pos, _ := obj[uast.KeyPos].(nodes.Object)
+	start, ok1 := offsetOf(pos[uast.KeyStart])
+	end, ok2 := offsetOf(pos[uast.KeyEnd])
+	if !ok1 || !ok2 || end <= start {
+		return nil
+	}
+
+	return &example{source: source, start: start, end: end}
+}
+
+// offsetOf returns the offset of the position node. Unlike uast.PositionsOf
+// it does not panic if the node cannot be decoded.
+func offsetOf(n nodes.Node) (uint32, bool) {
+	obj, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(obj) != uast.TypePosition {
+		return 0, false
+	}
+
+	var p uast.Position
+	if err := uast.NodeAs(obj, &p); err != nil || !p.HasOffset() {
+		return 0, false
+	}
+
+	return p.Offset, true
+}
+
+// smallerThan returns true if the example spans less source code than the
+// other one, or if there is no other example.
+func (ex *example) smallerThan(other *example) bool {
+	return other == nil || ex.end-ex.start < other.end-other.start
+}
+
+// snippet returns the source code of the example node, cut to
+// maxExampleLines.
+func (ex *example) snippet() (string, error) {
+	data, err := ioutil.ReadFile(ex.source)
+	if err != nil {
+		return "", err
+	} else if int(ex.end) > len(data) {
+		return "", fmt.Errorf("offset %d is out of %s", ex.end, ex.source)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data[ex.start:ex.end]), "\n"), "\n")
+	if len(lines) > maxExampleLines {
+		lines = append(lines[:maxExampleLines], "...")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// writeExamples writes the source code of the example of the role for each
+// language.
+func writeExamples(w *bytes.Buffer, role *Role) {
+	var languages []string
+	for language := range role.Examples {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	written := false
+	for _, language := range languages {
+		code, err := role.Examples[language].snippet()
+		if err != nil {
+			log.Printf("unable to read example of %s for %s: %s", role.Name, language, err)
+			continue
+		}
+
+		if !written {
+			written = true
+			w.WriteString("**Examples**:\n\n")
+		}
+		fmt.Fprintf(w, "*%s*:\n\n```%s\n%s\n```\n\n", strings.Title(language), language, code)
+	}
+}
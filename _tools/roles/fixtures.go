@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/driver/manifest/discovery"
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/uastyaml"
+)
+
+const (
+	fixtureDir   = "fixtures"
+	annotatedExt = ".uast"
+	semanticExt  = ".sem.uast"
+)
+
+// findObserved counts how often each role appears on nodes of annotated
+// fixtures of a driver.
+func findObserved(d discovery.Driver, roles Roles) error {
+	dir := filepath.Join(repoPath(d), fixtureDir)
+	return countObserved(dir, d.Language, roles)
+}
+
+// countObserved counts roles of nodes in all annotated fixtures in the given
+// directory, along with the native types and role sets of these nodes. The
+// smallest node with each role is recorded as an example.
+func countObserved(dir, language string, roles Roles) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no fixtures found for %s in %s", language, dir)
+	} else if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasSuffix(name, annotatedExt) || strings.HasSuffix(name, semanticExt) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		root, err := uastyaml.Unmarshal(data)
+		if err != nil {
+			return fmt.Errorf("unable to decode %s: %v", name, err)
+		}
+
+		source := filepath.Join(dir, strings.TrimSuffix(name, annotatedExt))
+		nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+			obj, ok := n.(nodes.Object)
+			if !ok {
+				return true
+			}
+			arr, _ := obj[uast.KeyRoles].(nodes.Array)
+			if len(arr) == 0 {
+				return true
+			}
+			ex := newExample(obj, source)
+			var names []string
+			for _, v := range arr {
+				if r, ok := v.(nodes.String); ok {
+					roles.ObservedIn(string(r), language, uast.TypeOf(obj), ex)
+					names = append(names, string(r))
+				}
+			}
+			observeRoleSet(language, names)
+			return true
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const deprecatedPrefix = "Deprecated:"
+
+var (
+	lint      = flag.Bool("lint", false, "list undocumented, unused and deprecated roles, fail if any role is undocumented")
+	allowFile = flag.String("allow", "", "file with names of roles to ignore in -lint, one per line")
+)
+
+// IsDocumented returns true if the role has a doc comment.
+func (r *Role) IsDocumented() bool {
+	return strings.TrimSpace(r.Doc) != ""
+}
+
+// IsDeprecated returns true if the doc comment of the role has a paragraph
+// starting with "Deprecated:", as for any other Go identifier.
+func (r *Role) IsDeprecated() bool {
+	for _, line := range strings.Split(r.Doc, "\n") {
+		if strings.HasPrefix(line, deprecatedPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsUnused returns true if no driver uses the role, neither in the code nor in
+// the fixtures.
+func (r *Role) IsUnused() bool {
+	for _, l := range r.Languages {
+		if len(l) > 0 {
+			return false
+		}
+	}
+	for _, n := range r.Observed {
+		if n > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readAllowlist reads names of roles to ignore in the lint mode. Empty lines
+// and lines starting with # are skipped. An empty path means no allowlist.
+func readAllowlist(path string) (map[string]bool, error) {
+	allow := make(map[string]bool)
+	if path == "" {
+		return allow, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		allow[line] = true
+	}
+
+	return allow, sc.Err()
+}
+
+// lintRoles writes lists of undocumented, unused and deprecated roles, skipping
+// the allowed ones. It returns an error if any role is undocumented.
+func lintRoles(w io.Writer, r Roles, allow map[string]bool) error {
+	var undocumented, unused, deprecated []string
+	for _, role := range r {
+		if allow[role.Name] {
+			continue
+		}
+
+		if !role.IsDocumented() {
+			undocumented = append(undocumented, role.Name)
+		}
+		if role.IsUnused() {
+			unused = append(unused, role.Name)
+		}
+		if role.IsDeprecated() {
+			deprecated = append(deprecated, role.Name)
+		}
+	}
+
+	writeLintList(w, "Undocumented roles", undocumented)
+	writeLintList(w, "Unused roles", unused)
+	writeLintList(w, "Deprecated roles", deprecated)
+
+	if len(undocumented) > 0 {
+		return fmt.Errorf("%d roles are not documented", len(undocumented))
+	}
+
+	return nil
+}
+
+func writeLintList(w io.Writer, title string, l []string) {
+	fmt.Fprintf(w, "## %s\n\n", title)
+	if len(l) == 0 {
+		fmt.Fprint(w, "None.\n\n")
+		return
+	}
+
+	for _, name := range l {
+		fmt.Fprintf(w, " - %s\n", name)
+	}
+
+	fmt.Fprint(w, "\n")
+}
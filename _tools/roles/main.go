@@ -4,30 +4,78 @@ package main
 import (
 	"bytes"
 	"context"
+	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/constant"
 	"go/token"
+	"go/types"
 	"log"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/bblfsh/sdk/v3/driver/manifest/discovery"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
 )
 
 const (
-	// UASTPackage  package containing the roles definition.
-	UASTPackage = "gopkg.in/bblfsh/sdk.v2/uast"
-	// RoleType go type name of the Role type
-	RoleType = UASTPackage + ".Role"
-	// GitHubFilePattern route to the annotation.go file at GitHub
-	GitHubFilePattern = "https://github.com/bblfsh/%s-driver/blob/master/driver/normalizer/annotation.go"
+	// GitHubFilePattern route to the annotation.go file at GitHub, at a given revision
+	GitHubFilePattern = "https://github.com/bblfsh/%s-driver/blob/%s/driver/normalizer/annotation.go"
+	// GitHubLinePattern route to a line of a file of a driver at GitHub, at a given revision
+	GitHubLinePattern = "https://github.com/bblfsh/%s-driver/blob/%s/%s#L%d"
+	// maxUsageLinks max number of usages linked for each language of a role
+	maxUsageLinks = 10
+	// maxNativeTypes max number of native types listed for each language of a role
+	maxNativeTypes = 10
 )
 
+// SDK is a major version of the SDK defining the roles.
+type SDK struct {
+	Major int
+	// Module is the import path of the SDK.
+	Module string
+}
+
+// RolePackage returns the package containing the roles definition.
+func (s SDK) RolePackage() string {
+	return s.Module + "/uast/role"
+}
+
+// RoleType returns the go type name of the Role type.
+func (s SDK) RoleType() string {
+	return s.RolePackage() + ".Role"
+}
+
+func (s SDK) String() string {
+	return fmt.Sprintf("v%d", s.Major)
+}
+
 var (
+	// LatestSDK is the SDK version roles are documented for.
+	LatestSDK = SDK{Major: 3, Module: "github.com/bblfsh/sdk/v3"}
+	// SDKs is the list of SDK versions drivers are checked against.
+	SDKs = []SDK{
+		LatestSDK,
+		{Major: 2, Module: "gopkg.in/bblfsh/sdk.v2"},
+	}
+
 	// OfficialDriver list of official driver maintanained by bblfsh org.
 	OfficialDriver []discovery.Driver
+	// DriverSDK is the SDK version used by the normalizer of each language.
+	DriverSDK = make(map[string]SDK)
+	// DriverRev is the analyzed commit of the driver of each language.
+	DriverRev = make(map[string]string)
+	// OutdatedRoles is the list of roles of each older SDK used by drivers,
+	// which are missing from the latest SDK.
+	OutdatedRoles = make(map[SDK]Roles)
+
+	report = flag.String("report", "roles", "Markdown report to print: roles, or sets for role sets found in fixtures")
 )
 
 func main() {
@@ -37,11 +85,86 @@ func main() {
 }
 
 func run() error {
-	roles, err := findRoles()
+	flag.Parse()
+	if *changelog {
+		return runChangelog(flag.Args())
+	}
+
+	roles, err := findRoles("", LatestSDK.RolePackage())
 	if err != nil {
 		return err
 	}
 
+	if err := listDrivers(); err != nil {
+		return err
+	}
+
+	results := loadAll(OfficialDriver)
+
+	// failures of drivers are only warnings when linting, as the roles are
+	// still linted against the drivers that were loaded
+	var last error
+	failed := func(err error) {
+		if *lint {
+			log.Println("warning:", err)
+			return
+		}
+		log.Println(err)
+		last = err
+	}
+	for i, d := range OfficialDriver {
+		res := results[i]
+		if res.syncErr != nil {
+			failed(res.syncErr)
+		}
+		if res.err != nil {
+			failed(res.err)
+		} else if res.loaded {
+			DriverSDK[d.Language] = res.sdk
+			DriverRev[d.Language] = res.rev
+		}
+	}
+
+	if err := findOutdatedRoles(roles); err != nil {
+		failed(err)
+	}
+
+	for i, d := range OfficialDriver {
+		res := results[i]
+		known := rolesOf(roles, d.Language)
+		if res.err == nil && res.loaded {
+			for _, u := range res.uses {
+				known.UsedBy(u.Name, d.Language, u.Pos)
+			}
+		}
+		if err := findObserved(d, known); err != nil {
+			// fixtures are optional
+			log.Println(err)
+		}
+	}
+
+	if *lint {
+		allow, err := readAllowlist(*allowFile)
+		if err != nil {
+			return err
+		}
+		return lintRoles(os.Stdout, roles, allow)
+	}
+
+	if flag.NArg() == 0 {
+		fmt.Print(formatMarkdown(roles))
+		return last
+	}
+	for _, fname := range flag.Args() {
+		if err := writeFile(fname, roles); err != nil {
+			return err
+		}
+	}
+	return last
+}
+
+// listDrivers sets OfficialDriver to the list of recommended official drivers.
+func listDrivers() error {
 	list, err := discovery.OfficialDrivers(context.TODO(), &discovery.Options{
 		NoMaintainers: true,
 	})
@@ -56,93 +179,276 @@ func run() error {
 	}
 	OfficialDriver = list
 
-	var last error
+	return nil
+}
+
+// findOutdatedRoles sets OutdatedRoles to the roles of each older SDK used by
+// drivers, which are missing from the given roles of the latest SDK. Roles are
+// loaded in the module of one of the drivers using the SDK version.
+func findOutdatedRoles(latest Roles) error {
+	names := make(map[string]bool, len(latest))
+	for _, role := range latest {
+		names[role.Name] = true
+	}
+
 	for _, d := range OfficialDriver {
-		if !d.IsRecommended() {
+		sdk, ok := DriverSDK[d.Language]
+		if !ok || sdk == LatestSDK {
+			continue
+		} else if _, ok := OutdatedRoles[sdk]; ok {
 			continue
 		}
-		pkg := strings.TrimPrefix(d.RepositoryURL(), "https://")
-		pkg += "/driver/normalizer"
-		if err := findUsage(d.Language, pkg, roles); err != nil {
-			log.Println(err)
-			last = err
+
+		roles, err := findRoles(repoPath(d), sdk.RolePackage())
+		if err != nil {
+			return fmt.Errorf("failed to find roles of SDK %s: %v", sdk, err)
+		}
+
+		missing := Roles{}
+		for _, role := range roles {
+			if !names[role.Name] {
+				missing = append(missing, role)
+			}
 		}
+		OutdatedRoles[sdk] = missing
 	}
 
-	fmt.Println(roles)
-	return last
+	return nil
+}
+
+// rolesOf returns the roles the driver of the given language may use: the
+// roles of the latest SDK, along with the outdated ones of the SDK used by
+// the driver, if any.
+func rolesOf(latest Roles, language string) Roles {
+	sdk, ok := DriverSDK[language]
+	if !ok || len(OutdatedRoles[sdk]) == 0 {
+		return latest
+	}
+
+	out := make(Roles, 0, len(latest)+len(OutdatedRoles[sdk]))
+	out = append(out, latest...)
+	return append(out, OutdatedRoles[sdk]...)
 }
 
-// findRoles find the roles defined at the uast package.
-func findRoles() (Roles, error) {
+// loadMode is the information required from packages to find roles.
+// Dependencies are type-checked from source, as export data may be unavailable.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedImports |
+	packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo
+
+// findRoles find the roles defined at the given role package, loaded from
+// dir, or from the current module if dir is empty.
+func findRoles(dir, rolePkg string) (Roles, error) {
 	var out Roles
 
-	conf := loader.Config{ParserMode: parser.ParseComments}
-	conf.Import(UASTPackage)
-	prog, err := conf.Load()
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode, Dir: dir}, rolePkg)
 	if err != nil {
 		return nil, err
+	} else if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package for %s, got %d", rolePkg, len(pkgs))
+	} else if err := packageErrors(pkgs[0]); err != nil {
+		return nil, err
 	}
 
-	pkg := prog.Package(UASTPackage).Pkg
-	for _, name := range pkg.Scope().Names() {
-		obj := pkg.Scope().Lookup(name)
-		if obj.Type().String() != RoleType {
+	pkg := pkgs[0]
+	roleType := pkg.PkgPath + ".Role"
+	for _, name := range pkg.Types.Scope().Names() {
+		// only constants are roles, not the Role type itself
+		obj, ok := pkg.Types.Scope().Lookup(name).(*types.Const)
+		if !ok || obj.Type().String() != roleType {
 			continue
 		}
 
+		value, _ := constant.Int64Val(obj.Val())
+
 		out = append(out, &Role{
 			Name:      obj.Name(),
-			Doc:       findDoc(prog, obj.Pos()).Text(),
+			Value:     int(value),
+			Doc:       findDoc(pkg, obj.Pos()).Text(),
 			Languages: make(map[string][]token.Position),
+			Observed:  make(map[string]int),
+			Native:    make(map[string]map[string]int),
+			Examples:  make(map[string]*example),
 		})
 	}
 
 	return out, nil
 }
 
-func findDoc(prog *loader.Program, pos token.Pos) *ast.CommentGroup {
-	_, path, _ := prog.PathEnclosingInterval(pos, pos)
-	for _, n := range path {
-		n, ok := n.(*ast.ValueSpec)
-		if !ok {
+func findDoc(pkg *packages.Package, pos token.Pos) *ast.CommentGroup {
+	for _, f := range pkg.Syntax {
+		if pos < f.Pos() || pos > f.End() {
 			continue
 		}
 
-		return n.Doc
+		path, _ := astutil.PathEnclosingInterval(f, pos, pos)
+		for _, n := range path {
+			n, ok := n.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+
+			return n.Doc
+		}
 	}
 
 	return nil
 }
 
-// findUsage finds in the normalizer package of a driver which roles are being
-// used.
-func findUsage(language, pkg string, roles Roles) error {
-	conf := loader.Config{ParserMode: parser.ParseComments}
-	conf.Import(pkg) // TODO: clone it if not found
-	prog, err := conf.Load()
+// usage is a single reference to a role in the code of a driver, the file
+// name of the position is relative to the root of the driver repository.
+type usage struct {
+	Name string
+	Pos  token.Position
+}
+
+// driverResult is the outcome of syncing and loading a single driver.
+type driverResult struct {
+	syncErr error
+	loaded  bool
+	rev     string
+	sdk     SDK
+	uses    []usage
+	err     error
+}
+
+// loadAll syncs and loads normalizers of all drivers in parallel. Failures
+// are recorded for each driver separately, thus one driver that fails to
+// build does not prevent the others from being reported.
+func loadAll(drivers []discovery.Driver) []driverResult {
+	var (
+		results  = make([]driverResult, len(drivers))
+		throttle = make(chan int, 3) // drivers to load in parallel
+		wg       sync.WaitGroup
+	)
+	for i := range drivers {
+		d, res := drivers[i], &results[i]
+		wg.Add(1)
+		go func() {
+			throttle <- 1
+			defer func() { <-throttle; wg.Done() }()
+
+			if !*skipUpdate {
+				res.syncErr = maybeCloneOrPull(d)
+			}
+			if _, err := os.Stat(repoPath(d)); err != nil {
+				// no clone to analyze, the error is already reported
+				return
+			}
+			if *report == "sets" {
+				// only fixtures are analyzed
+				return
+			}
+
+			res.rev, res.err = gitOutput(repoPath(d), "rev-parse", "HEAD")
+			if res.err == nil {
+				res.sdk, res.uses, res.err = findUsage(repoPath(d))
+			}
+			if res.err != nil {
+				res.err = fmt.Errorf("%s: %v", d.Language, res.err)
+			}
+			res.loaded = true
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// findUsage finds in the normalizer package of a driver cloned to dir which
+// roles are being used. The package is loaded in the module of the driver,
+// thus with the SDK version required in its go.mod. Roles are matched by
+// name, thus drivers using an older SDK are reported along with the ones
+// using the latest one.
+func findUsage(dir string) (SDK, []usage, error) {
+	pkg := "./" + normalizerDir
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode, Dir: dir}, pkg)
 	if err != nil {
-		return err
+		return SDK{}, nil, err
+	} else if len(pkgs) != 1 {
+		return SDK{}, nil, fmt.Errorf("expected one package for %s, got %d", pkg, len(pkgs))
+	} else if err := packageErrors(pkgs[0]); err != nil {
+		return SDK{}, nil, err
 	}
 
-	info := prog.Package(pkg)
-	for id, obj := range info.Uses {
-		if obj.Type().String() != RoleType {
+	info := pkgs[0]
+	sdk, ok := detectSDK(info)
+	if !ok {
+		return SDK{}, nil, fmt.Errorf("no known SDK version is imported by %s", pkg)
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return SDK{}, nil, err
+	}
+
+	var uses []usage
+	for id, obj := range info.TypesInfo.Uses {
+		if obj.Type().String() != sdk.RoleType() {
 			continue
 		}
 
-		roles.UsedBy(obj.Name(), language, prog.Fset.Position(id.Pos()))
+		pos := info.Fset.Position(id.Pos())
+		if rel, err := filepath.Rel(root, pos.Filename); err == nil {
+			pos.Filename = filepath.ToSlash(rel)
+		}
+
+		uses = append(uses, usage{Name: obj.Name(), Pos: pos})
 	}
 
-	return nil
+	// Uses is a map, keep positions in a stable order
+	sort.Slice(uses, func(i, j int) bool {
+		a, b := uses[i].Pos, uses[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
 
+	return sdk, uses, nil
+}
+
+// packageErrors returns an error listing all errors of the package, if any.
+func packageErrors(pkg *packages.Package) error {
+	if len(pkg.Errors) == 0 {
+		return nil
+	}
+
+	var l []string
+	for _, err := range pkg.Errors {
+		l = append(l, err.Error())
+	}
+
+	return fmt.Errorf("%d errors loading %s:\n%s", len(l), pkg.PkgPath, strings.Join(l, "\n"))
+}
+
+// detectSDK returns the SDK version the roles package of which is imported
+// by the given package.
+func detectSDK(pkg *packages.Package) (SDK, bool) {
+	for _, sdk := range SDKs {
+		if _, ok := pkg.Imports[sdk.RolePackage()]; ok {
+			return sdk, true
+		}
+	}
+
+	return SDK{}, false
 }
 
 // Role contains the relevant information of a Role definition
 type Role struct {
-	Name      string
-	Doc       string
+	Name string
+	// Value is the numeric value of the role constant.
+	Value int
+	Doc   string
+	// Languages is the list of usages in the code of the drivers, per language.
+	// File names are relative to the root of the driver repository.
 	Languages map[string][]token.Position
+	// Observed is the number of nodes with this role in fixtures, per language.
+	Observed map[string]int
+	// Native is the number of nodes with this role in fixtures, per language
+	// and native type of the node.
+	Native map[string]map[string]int
+	// Examples is the smallest node with this role in fixtures, per language.
+	Examples map[string]*example
 }
 
 func (r *Role) IsUsedBy(language string) bool {
@@ -157,6 +463,25 @@ func (r *Role) IsUsedBy(language string) bool {
 // Roles is a list of roles.
 type Roles []*Role
 
+// ObservedIn increments the number of nodes with a specific role and native
+// type found in fixtures of the given language. The example of the node is
+// kept, if it is smaller than the one recorded previously.
+func (r Roles) ObservedIn(name, language, typ string, ex *example) {
+	for _, role := range r {
+		if role.Name == name {
+			role.Observed[language]++
+			if role.Native[language] == nil {
+				role.Native[language] = make(map[string]int)
+			}
+			role.Native[language][typ]++
+			if ex != nil && ex.smallerThan(role.Examples[language]) {
+				role.Examples[language] = ex
+			}
+			return
+		}
+	}
+}
+
 // UsedBy adds the given language to the list of language using a specific role.
 func (r Roles) UsedBy(name, language string, pos token.Position) {
 	for _, role := range r {
@@ -175,12 +500,17 @@ func (r Roles) UsedBy(name, language string, pos token.Position) {
 const documentHeader = "" +
 	"# Roles list\n\n" +
 	"Role is the main UAST annotation. It indicates that a node in an AST " +
-	"can be interpreted as acting with certain language-independent role.\n\n"
+	"can be interpreted as acting with certain language-independent role.\n\n" +
+	"A check mark means that the role is declared in the normalizer code of " +
+	"the driver, and a number is how many nodes have this role in the fixtures " +
+	"of the driver.\n\n"
 
 func (r Roles) String() string {
 	buf := bytes.NewBuffer([]byte(documentHeader))
 	writeTableHeader(buf)
 	writeTableBody(buf, r)
+	writeOutdated(buf)
+	writeOutdatedRoles(buf)
 	writeList(buf, r)
 
 	return buf.String()
@@ -189,7 +519,11 @@ func (r Roles) String() string {
 func writeTableHeader(w *bytes.Buffer) {
 	var list []string
 	for _, d := range OfficialDriver {
-		list = append(list, d.Name)
+		name := d.Name
+		if sdk, ok := DriverSDK[d.Language]; ok && sdk != LatestSDK {
+			name += " (SDK " + sdk.String() + ")"
+		}
+		list = append(list, name)
 	}
 
 	w.WriteString("Role |" + strings.Join(list, " | ") + "\n")
@@ -200,12 +534,15 @@ func writeTableBody(w *bytes.Buffer, r Roles) {
 	for _, role := range r {
 		fmt.Fprintf(w, "[%s](#%s) ", role.Name, strings.ToLower(role.Name))
 		for _, d := range OfficialDriver {
-			var used string
+			var used []string
 			if role.IsUsedBy(d.Language) {
-				used = "✓"
+				used = append(used, "✓")
+			}
+			if n := role.Observed[d.Language]; n > 0 {
+				used = append(used, strconv.Itoa(n))
 			}
 
-			fmt.Fprintf(w, " | %s", used)
+			fmt.Fprintf(w, " | %s", strings.Join(used, " "))
 		}
 
 		fmt.Fprint(w, "\n")
@@ -214,20 +551,162 @@ func writeTableBody(w *bytes.Buffer, r Roles) {
 	fmt.Fprint(w, "\n\n")
 }
 
+// writeOutdated lists drivers which normalizers are not using the latest SDK.
+func writeOutdated(w *bytes.Buffer) {
+	var l []string
+	for _, d := range OfficialDriver {
+		if sdk, ok := DriverSDK[d.Language]; ok && sdk != LatestSDK {
+			l = append(l, fmt.Sprintf("%s (SDK %s)", d.Name, sdk))
+		}
+	}
+	if len(l) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "**Drivers on an old SDK**: %s. Their roles are matched by name "+
+		"to the roles of SDK %s.\n\n", strings.Join(l, ", "), LatestSDK)
+}
+
+// writeOutdatedRoles lists roles used by drivers on an old SDK, which are
+// missing from the latest SDK.
+func writeOutdatedRoles(w *bytes.Buffer) {
+	for _, sdk := range SDKs {
+		roles := OutdatedRoles[sdk]
+		if len(roles) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "**Roles of SDK %s missing from SDK %s**:\n\n", sdk, LatestSDK)
+		for _, role := range roles {
+			var l []string
+			for _, d := range OfficialDriver {
+				declared, observed := len(role.Languages[d.Language]), role.Observed[d.Language]
+				if declared == 0 && observed == 0 {
+					continue
+				}
+				l = append(l, fmt.Sprintf("*%s* (✓ %d, observed %d)", strings.Title(d.Language), declared, observed))
+			}
+			if len(l) == 0 {
+				l = append(l, "unused")
+			}
+			fmt.Fprintf(w, "- `%s`: %s\n", role.Name, strings.Join(l, ", "))
+		}
+		fmt.Fprint(w, "\n")
+	}
+}
+
 func writeList(w *bytes.Buffer, r Roles) {
 	for _, role := range r {
 
-		var l []string
+		var languages []string
 		for language := range role.Languages {
-			l = append(l, fmt.Sprintf("[*%s*](%s)",
+			languages = append(languages, language)
+		}
+		sort.Strings(languages)
+
+		var l []string
+		for _, language := range languages {
+			l = append(l, fmt.Sprintf("[*%s*](%s) (%d)",
 				strings.Title(language),
-				fmt.Sprintf(GitHubFilePattern, language),
+				fmt.Sprintf(GitHubFilePattern, language, driverRev(language)),
+				len(role.Languages[language]),
 			))
 		}
-		sort.Strings(l)
+
+		var o []string
+		for language, n := range role.Observed {
+			o = append(o, fmt.Sprintf("*%s* (%d)", strings.Title(language), n))
+		}
+		sort.Strings(o)
 
 		fmt.Fprintf(w, "## %s\n\n%s\n**Supported by**: %s\n\n",
 			role.Name, role.Doc, strings.Join(l, ", "),
 		)
+		for _, language := range languages {
+			writeUsages(w, language, role.Languages[language])
+		}
+		if len(languages) > 0 {
+			fmt.Fprint(w, "\n")
+		}
+		if len(o) > 0 {
+			fmt.Fprintf(w, "**Observed in fixtures**: %s\n\n", strings.Join(o, ", "))
+		}
+		writeNativeTypes(w, role)
+		writeExamples(w, role)
+	}
+}
+
+// writeNativeTypes writes a table of native types of nodes having the role in
+// fixtures, for each language.
+func writeNativeTypes(w *bytes.Buffer, role *Role) {
+	if len(role.Native) == 0 {
+		return
+	}
+
+	var languages []string
+	for language := range role.Native {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	w.WriteString("Language | Native types\n")
+	w.WriteString("---|---\n")
+	for _, language := range languages {
+		types := role.Native[language]
+
+		var names []string
+		for name := range types {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if types[names[i]] != types[names[j]] {
+				return types[names[i]] > types[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		var l []string
+		for i, name := range names {
+			if i == maxNativeTypes {
+				l = append(l, fmt.Sprintf("and %d more", len(names)-i))
+				break
+			}
+			if name == "" {
+				name = "(no type)"
+			}
+			l = append(l, fmt.Sprintf("`%s` (%d)", name, types[names[i]]))
+		}
+
+		fmt.Fprintf(w, "%s | %s\n", strings.Title(language), strings.Join(l, ", "))
+	}
+
+	fmt.Fprint(w, "\n")
+}
+
+// writeUsages writes links to the exact lines using the role in the code of
+// the driver of the given language.
+func writeUsages(w *bytes.Buffer, language string, l []token.Position) {
+	var links []string
+	for i, pos := range l {
+		if i == maxUsageLinks {
+			links = append(links, fmt.Sprintf("and %d more", len(l)-i))
+			break
+		}
+
+		links = append(links, fmt.Sprintf("[%s:%d](%s)",
+			path.Base(pos.Filename), pos.Line,
+			fmt.Sprintf(GitHubLinePattern, language, driverRev(language), pos.Filename, pos.Line),
+		))
 	}
+
+	fmt.Fprintf(w, "- *%s*: %s\n", strings.Title(language), strings.Join(links, ", "))
+}
+
+// driverRev returns the analyzed revision of the driver of the given language.
+func driverRev(language string) string {
+	if rev, ok := DriverRev[language]; ok {
+		return rev
+	}
+
+	return "master"
 }
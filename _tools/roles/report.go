@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// Report is the machine-readable version of the roles report.
+type Report struct {
+	Drivers []DriverReport
+	Roles   []RoleReport
+	// Outdated lists roles of older SDKs used by drivers, which are missing
+	// from the latest SDK, keyed by the SDK version.
+	Outdated map[string][]RoleReport `json:",omitempty"`
+}
+
+// DriverReport describes an analyzed driver.
+type DriverReport struct {
+	Language string
+	Name     string
+	URL      string
+	SDK      string `json:",omitempty"`
+	Revision string `json:",omitempty"`
+}
+
+// RoleReport describes a role and its usage in each language.
+type RoleReport struct {
+	Name      string
+	Value     int
+	Doc       string
+	Languages map[string]LanguageReport `json:",omitempty"`
+}
+
+// LanguageReport describes the usage of a role by the driver of a language.
+type LanguageReport struct {
+	// Declared is the number of references to the role in the code of the driver.
+	Declared int
+	// Observed is the number of nodes with the role in the fixtures of the driver.
+	Observed    int
+	Usages      []UsageReport  `json:",omitempty"`
+	NativeTypes map[string]int `json:",omitempty"`
+}
+
+// UsageReport is a reference to the role in the code of a driver.
+type UsageReport struct {
+	File   string
+	Line   int
+	Column int
+	URL    string
+}
+
+func newReport(r Roles) Report {
+	var rep Report
+	for _, d := range OfficialDriver {
+		dr := DriverReport{
+			Language: d.Language,
+			Name:     d.Name,
+			URL:      d.RepositoryURL(),
+			Revision: DriverRev[d.Language],
+		}
+		if sdk, ok := DriverSDK[d.Language]; ok {
+			dr.SDK = sdk.String()
+		}
+		rep.Drivers = append(rep.Drivers, dr)
+	}
+
+	for _, role := range r {
+		rep.Roles = append(rep.Roles, newRoleReport(role))
+	}
+	for sdk, roles := range OutdatedRoles {
+		if len(roles) == 0 {
+			continue
+		}
+		if rep.Outdated == nil {
+			rep.Outdated = make(map[string][]RoleReport)
+		}
+		for _, role := range roles {
+			rep.Outdated[sdk.String()] = append(rep.Outdated[sdk.String()], newRoleReport(role))
+		}
+	}
+
+	return rep
+}
+
+// newRoleReport describes the role and its usage in each language.
+func newRoleReport(role *Role) RoleReport {
+	rr := RoleReport{
+		Name:      role.Name,
+		Value:     role.Value,
+		Doc:       role.Doc,
+		Languages: make(map[string]LanguageReport),
+	}
+
+	var languages []string
+	for language := range role.Languages {
+		languages = append(languages, language)
+	}
+	for language := range role.Observed {
+		if _, ok := role.Languages[language]; !ok {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+
+	for _, language := range languages {
+		lr := LanguageReport{
+			Declared:    len(role.Languages[language]),
+			Observed:    role.Observed[language],
+			NativeTypes: role.Native[language],
+		}
+		for _, pos := range role.Languages[language] {
+			lr.Usages = append(lr.Usages, UsageReport{
+				File:   pos.Filename,
+				Line:   pos.Line,
+				Column: pos.Column,
+				URL:    fmt.Sprintf(GitHubLinePattern, language, driverRev(language), pos.Filename, pos.Line),
+			})
+		}
+		rr.Languages[language] = lr
+	}
+
+	return rr
+}
+
+// formatMarkdown renders the Markdown report selected by the report flag.
+func formatMarkdown(r Roles) string {
+	switch *report {
+	case "sets":
+		return formatRoleSets()
+	default:
+		return r.String() + "\n"
+	}
+}
+
+// writeFile writes the report to a file, the format is selected by the
+// file extension.
+func writeFile(fname string, r Roles) error {
+	const filePerm = 0644
+	switch filepath.Ext(fname) {
+	case ".json":
+		if *report != "roles" {
+			return fmt.Errorf("JSON is only supported for the roles report, not %q", *report)
+		}
+		data, err := json.MarshalIndent(newReport(r), "", "\t")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(fname, data, filePerm)
+	case ".md":
+		fallthrough
+	default:
+	}
+
+	return ioutil.WriteFile(fname, []byte(formatMarkdown(r)), filePerm)
+}
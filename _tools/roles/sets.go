@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxRoleSets max number of the most frequent role sets listed for each language.
+const maxRoleSets = 50
+
+// RoleSets is the number of nodes in fixtures with a given set of roles, per
+// language. Sets are identified by the sorted role names, joined by a comma.
+var RoleSets = make(map[string]map[string]int)
+
+// observeRoleSet increments the number of nodes with the given set of roles
+// found in fixtures of the given language.
+func observeRoleSet(language string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	names = append([]string{}, names...)
+	sort.Strings(names)
+	if RoleSets[language] == nil {
+		RoleSets[language] = make(map[string]int)
+	}
+
+	RoleSets[language][strings.Join(names, ", ")]++
+}
+
+// sortedSets returns role sets of the counts map, the most frequent first.
+func sortedSets(counts map[string]int) []string {
+	var sets []string
+	for set := range counts {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if counts[sets[i]] != counts[sets[j]] {
+			return counts[sets[i]] > counts[sets[j]]
+		}
+		return sets[i] < sets[j]
+	})
+
+	return sets
+}
+
+const roleSetsHeader = "" +
+	"# Role sets\n\n" +
+	"Roles are agglutinative: the meaning of a node is given by the whole set " +
+	"of its roles, not by a single role. This document lists the distinct role " +
+	"sets of nodes found in the fixtures of each driver.\n\n"
+
+// formatRoleSets renders the role sets analysis as Markdown: a matrix of sets
+// shared by several languages, followed by the most frequent sets of each
+// language.
+func formatRoleSets() string {
+	buf := bytes.NewBuffer([]byte(roleSetsHeader))
+
+	var languages, names []string
+	total := make(map[string]int)
+	for _, d := range OfficialDriver {
+		if len(RoleSets[d.Language]) == 0 {
+			continue
+		}
+
+		languages = append(languages, d.Language)
+		names = append(names, d.Name)
+		for set, n := range RoleSets[d.Language] {
+			total[set] += n
+		}
+	}
+
+	var shared []string
+	usedBy := make(map[string]int)
+	for set := range total {
+		for _, language := range languages {
+			if RoleSets[language][set] > 0 {
+				usedBy[set]++
+			}
+		}
+		if usedBy[set] > 1 {
+			shared = append(shared, set)
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		a, b := shared[i], shared[j]
+		if usedBy[a] != usedBy[b] {
+			return usedBy[a] > usedBy[b]
+		}
+		if total[a] != total[b] {
+			return total[a] > total[b]
+		}
+		return a < b
+	})
+
+	buf.WriteString("## Shared role sets\n\n")
+	buf.WriteString("Role sets found in fixtures of more than one language, " +
+		"with the number of nodes having exactly this set of roles.\n\n")
+	buf.WriteString("Role set | " + strings.Join(names, " | ") + "\n")
+	buf.WriteString("---" + strings.Repeat("|---", len(names)) + "\n")
+	for _, set := range shared {
+		fmt.Fprintf(buf, "%s ", set)
+		for _, language := range languages {
+			var cell string
+			if n := RoleSets[language][set]; n > 0 {
+				cell = fmt.Sprint(n)
+			}
+
+			fmt.Fprintf(buf, " | %s", cell)
+		}
+
+		fmt.Fprint(buf, "\n")
+	}
+	fmt.Fprint(buf, "\n")
+
+	for i, language := range languages {
+		sets := sortedSets(RoleSets[language])
+		fmt.Fprintf(buf, "## %s\n\n%d distinct role sets.\n\n", names[i], len(sets))
+		buf.WriteString("Role set | Nodes\n")
+		buf.WriteString("---|---\n")
+		for j, set := range sets {
+			if j == maxRoleSets {
+				break
+			}
+
+			fmt.Fprintf(buf, "%s | %d\n", set, RoleSets[language][set])
+		}
+		if len(sets) > maxRoleSets {
+			fmt.Fprintf(buf, "\n... and %d less frequent sets\n", len(sets)-maxRoleSets)
+		}
+
+		fmt.Fprint(buf, "\n")
+	}
+
+	return buf.String()
+}
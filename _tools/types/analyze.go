@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fixtureJob is a single fixture file to analyze.
+type fixtureJob struct {
+	driver *driverStats // driver the fixture belongs to
+	path   string       // path to the fixture file
+	res    *driverStats // results of the analysis
+	cached bool         // results were loaded from the cache
+	err    error
+	done   chan struct{} // closed when the results are ready
+}
+
+// analyzeAllFixtures goes though all fixtures of all drivers, assuming the drivers are cloned.
+// Fixture files are analyzed by a bounded pool of workers, and the results are merged in
+// the order of drivers and file names as soon as they are ready, thus the output does not
+// depend on the scheduling. At most a few files per worker are in flight at any time, so the
+// memory use does not depend on the number of fixtures.
+// Unless disabled, results for every file are cached, thus unchanged files are not analyzed again.
+// It updates given driverStats with results.
+func analyzeAllFixtures(drivers []*driverStats, uastTypes []uastType, workers int) error {
+	schema := make(map[string]uastType, len(uastTypes))
+	for _, typee := range uastTypes {
+		schema[typee.name] = typee
+	}
+
+	var jobs []*fixtureJob
+	for _, driver := range drivers {
+		fixDir := filepath.Join(reposRootPath, driver.path, fixtureDir)
+		fixtureFiles, err := lsDir(fixDir)
+		if os.IsNotExist(err) {
+			driver.skip = true
+			continue
+		} else if err != nil {
+			return err
+		}
+		driver.fixtureCount += len(fixtureFiles)
+
+		for _, file := range fixtureFiles {
+			name := file.Name()
+			if !strings.HasSuffix(name, fixtureExt) && !strings.HasSuffix(name, nativeExt) {
+				continue
+			}
+			jobs = append(jobs, &fixtureJob{
+				driver: driver,
+				path:   filepath.Join(fixDir, name),
+				done:   make(chan struct{}),
+			})
+		}
+	}
+
+	var cache *fixtureCache
+	if *cacheDir != "" {
+		cache = newFixtureCache(*cacheDir, uastTypes)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		queue = make(chan *fixtureJob)
+		// limits the number of results waiting to be merged
+		inFlight = make(chan struct{}, 2*workers)
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			var buf bytes.Buffer // reused for all files of the worker
+			for job := range queue {
+				job.res, job.cached, job.err = analyzeFixture(job.path, schema, &buf, cache)
+				close(job.done)
+			}
+		}()
+	}
+	go func() {
+		for _, job := range jobs {
+			inFlight <- struct{}{}
+			queue <- job
+		}
+		close(queue)
+	}()
+
+	cached := 0
+	for i, job := range jobs {
+		<-job.done
+		if job.cached {
+			cached++
+		}
+		if job.err != nil {
+			fmt.Fprintf(os.Stderr, "unable to analyze %q, skipping: %s\n", job.path, job.err)
+		} else {
+			job.driver.merge(job.res)
+		}
+		// release the results as soon as they are merged
+		jobs[i] = nil
+		<-inFlight
+	}
+	if cache != nil {
+		fmt.Fprintf(os.Stderr, "%d of %d fixture files loaded from cache\n", cached, len(jobs))
+	}
+	return nil
+}
+
+// merge adds fixture analysis results to the driver stats.
+func (d *driverStats) merge(st *driverStats) {
+	for k, v := range st.uastInFixturesCount {
+		d.uastInFixturesCount[k] += v
+	}
+	for k, v := range st.fieldsInFixtures {
+		d.fieldsInFixtures[k] += v
+	}
+	for k, v := range st.nativeInFixtures {
+		d.nativeInFixtures[k] += v
+	}
+	for k, v := range st.nativeInSemantic {
+		d.nativeInSemantic[k] += v
+	}
+	for k, ex := range st.examples {
+		if cur := d.examples[k]; cur == nil || ex.end-ex.start < cur.end-cur.start {
+			d.examples[k] = ex
+		}
+	}
+	d.positionsChecked += st.positionsChecked
+	d.positionErrors = append(d.positionErrors, st.positionErrors...)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/uastyaml"
+)
+
+// cacheVersion must be changed each time the analysis or the format of cached results changes.
+const cacheVersion = "2"
+
+// fixtureCache stores results of the analysis of fixture files on disk,
+// keyed by the hash of their content, thus only changed fixtures are re-analyzed.
+type fixtureCache struct {
+	dir  string
+	salt []byte // hash of everything else the analysis depends on
+}
+
+// cachedFixture is the analysis result of a single fixture file as stored in the cache.
+type cachedFixture struct {
+	Types            map[string]int           `json:"types,omitempty"`
+	Fields           map[string]int           `json:"fields,omitempty"`
+	Native           map[string]int           `json:"native,omitempty"`
+	NativeInSemantic map[string]int           `json:"native_in_semantic,omitempty"`
+	Examples         map[string]cachedExample `json:"examples,omitempty"`
+	PositionsChecked int                      `json:"positions_checked,omitempty"`
+	PositionErrors   []string                 `json:"position_errors,omitempty"`
+}
+
+// cachedExample is an example node, encoded as YAML.
+type cachedExample struct {
+	Node  string `json:"node"`
+	Start uint32 `json:"start"`
+	End   uint32 `json:"end"`
+}
+
+// newFixtureCache creates a cache in a given directory.
+// Results depend on the UAST types schema and on whether positions are validated,
+// thus both are included into the key.
+func newFixtureCache(dir string, uastTypes []uastType) *fixtureCache {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%s\npositions=%v\n", cacheVersion, *report == "positions")
+	for _, typee := range uastTypes {
+		fmt.Fprintf(h, "%s %s\n", typee.name, strings.Join(typee.fields, ","))
+	}
+	return &fixtureCache{dir: dir, salt: h.Sum(nil)}
+}
+
+// key returns the cache key for a fixture file with a given content.
+// Position errors mention the name of the fixture and positions are checked against
+// the source file, thus both of them are included into the key as well.
+func (c *fixtureCache) key(path string, data []byte) (string, error) {
+	h := sha256.New()
+	h.Write(c.salt)
+	fmt.Fprintf(h, "%s\n%d\n", filepath.Base(path), len(data))
+	h.Write(data)
+	if *report == "positions" && strings.HasSuffix(path, fixtureExt) {
+		src, err := ioutil.ReadFile(strings.TrimSuffix(path, fixtureExt))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		h.Write(src)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *fixtureCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// load returns cached results for a given key, or nil if there are none.
+// Results are returned for a fixture with a given path, as the content may be shared by multiple files.
+func (c *fixtureCache) load(key, path string) *driverStats {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	var cf cachedFixture
+	if err = json.Unmarshal(data, &cf); err != nil {
+		return nil
+	}
+	st := newStats()
+	merge := func(dst, src map[string]int) {
+		for k, v := range src {
+			dst[k] = v
+		}
+	}
+	merge(st.uastInFixturesCount, cf.Types)
+	merge(st.fieldsInFixtures, cf.Fields)
+	merge(st.nativeInFixtures, cf.Native)
+	merge(st.nativeInSemantic, cf.NativeInSemantic)
+	for name, ex := range cf.Examples {
+		n, err := uastyaml.Unmarshal([]byte(ex.Node))
+		if err != nil {
+			return nil
+		}
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return nil
+		}
+		st.examples[name] = &example{
+			source: strings.TrimSuffix(path, fixtureExt),
+			node:   obj,
+			start:  ex.Start,
+			end:    ex.End,
+		}
+	}
+	st.positionsChecked = cf.PositionsChecked
+	st.positionErrors = cf.PositionErrors
+	return st
+}
+
+// store saves results to the cache.
+// The file is written atomically, thus concurrent runs never observe partial results.
+func (c *fixtureCache) store(key string, st *driverStats) error {
+	cf := cachedFixture{
+		Types:            st.uastInFixturesCount,
+		Fields:           st.fieldsInFixtures,
+		Native:           st.nativeInFixtures,
+		NativeInSemantic: st.nativeInSemantic,
+		Examples:         make(map[string]cachedExample, len(st.examples)),
+		PositionsChecked: st.positionsChecked,
+		PositionErrors:   st.positionErrors,
+	}
+	for name, ex := range st.examples {
+		data, err := uastyaml.Marshal(ex.node)
+		if err != nil {
+			return err
+		}
+		cf.Examples[name] = cachedExample{Node: string(data), Start: ex.start, End: ex.end}
+	}
+	data, err := json.Marshal(cf)
+	if err != nil {
+		return err
+	}
+
+	fname := c.path(key)
+	if err = os.MkdirAll(filepath.Dir(fname), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(fname), "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), fname)
+}
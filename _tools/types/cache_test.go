@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureCacheKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "types-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "hello.go")
+	fixture := source + fixtureExt
+	data := []byte("'@type': 'uast:Identifier'\n")
+
+	writeSource := func(content string) {
+		if err := ioutil.WriteFile(source, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key := func(mode, path string, data []byte) string {
+		old := *report
+		defer func() { *report = old }()
+		*report = mode
+
+		c := newFixtureCache(dir, testTypes)
+		k, err := c.key(path, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	writeSource("package main\n")
+	cases := []struct {
+		name   string
+		mode   string
+		path   string
+		data   []byte
+		change func()
+		same   bool
+	}{
+		{name: "same input", mode: "positions", path: fixture, data: data, same: true},
+		{name: "source changed", mode: "positions", path: fixture, data: data,
+			change: func() { writeSource("package hello\n") }},
+		{name: "source removed", mode: "positions", path: fixture, data: data,
+			change: func() { os.Remove(source) }},
+		{name: "source changed without positions", mode: "types", path: fixture, data: data, same: true,
+			change: func() { writeSource("package hello\n") }},
+		{name: "source changed for native fixture", mode: "positions", path: source + nativeExt, data: data, same: true,
+			change: func() { writeSource("package hello\n") }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			writeSource("package main\n")
+			before := key(c.mode, c.path, c.data)
+			if c.change != nil {
+				c.change()
+			}
+			after := key(c.mode, c.path, c.data)
+			if c.same && before != after {
+				t.Fatalf("expected the key to stay the same")
+			} else if !c.same && before == after {
+				t.Fatalf("expected the key to change")
+			}
+		})
+	}
+
+	if key("types", fixture, data) == key("positions", fixture, data) {
+		t.Errorf("expected the key to depend on the report mode")
+	}
+	if key("types", fixture, data) == key("types", filepath.Join(dir, "other.go"+fixtureExt), data) {
+		t.Errorf("expected the key to depend on the fixture name")
+	}
+}
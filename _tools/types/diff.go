@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var mdLinkRe = regexp.MustCompile(`^\[([^\]]*)\]\(([^)]*)\)`)
+
+// readReport reads the types report, either in JSON or in Markdown format,
+// choosing the format based on the file extension.
+func readReport(fname string) ([]Driver, error) {
+	switch filepath.Ext(fname) {
+	case ".json":
+		data, err := ioutil.ReadFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		var list []Driver
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %s", fname, err)
+		}
+		return list, nil
+	case ".md":
+		fallthrough
+	default:
+	}
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseMarkdownTable(f)
+}
+
+// parseMarkdownTable parses the types table, as written by formatMarkdownTable.
+func parseMarkdownTable(r io.Reader) ([]Driver, error) {
+	var list []Driver
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if !strings.HasPrefix(line, "|") {
+			if list != nil {
+				break // end of the table
+			}
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		if list == nil {
+			// table header with links to drivers
+			list = make([]Driver, 0, len(cells)-1)
+			for _, c := range cells[1:] {
+				m := mdLinkRe.FindStringSubmatch(c)
+				if m == nil {
+					return nil, fmt.Errorf("unexpected driver in the table header: %q", c)
+				}
+				list = append(list, Driver{
+					Name:  m[1],
+					URL:   m[2],
+					Stale: strings.HasSuffix(c, "(stale)"),
+					Types: make(map[string]TypeUsage),
+				})
+			}
+			continue
+		}
+		m := mdLinkRe.FindStringSubmatch(cells[0])
+		if m == nil {
+			continue // header separator
+		}
+		if len(cells)-1 != len(list) {
+			return nil, fmt.Errorf("expected %d drivers for %s, got %d", len(list), m[1], len(cells)-1)
+		}
+		for i, c := range cells[1:] {
+			u, err := parseUsage(c)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse usage of %s: %s", m[1], err)
+			}
+			list[i].Types[m[1]] = u
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, fmt.Errorf("no types table found")
+	}
+	return list, nil
+}
+
+// parseUsage parses a single cell of the types table.
+func parseUsage(s string) (TypeUsage, error) {
+	var (
+		u   TypeUsage
+		err error
+	)
+	if s == "-" {
+		return u, nil
+	}
+	fixtures, code := s, ""
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		fixtures, code = s[:i], s[i+1:]
+	}
+	if u.Fixtures, err = strconv.Atoi(fixtures); err != nil {
+		return u, err
+	}
+	if code != "" {
+		if u.Code, err = strconv.Atoi(code); err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+// typeDelta is a change of UAST type usage by a driver between two reports.
+type typeDelta struct {
+	Driver   string
+	Type     string
+	Old, New TypeUsage
+	Fail     string // reason the change is considered a regression, if any
+}
+
+// diffReports compares two reports and returns all changes in the types usage.
+// Drivers are matched by their repository URL. Changes are reported as failed
+// if a usage count drops by more than threshold percent, or the type is no
+// longer used by the driver.
+func diffReports(old, cur []Driver, threshold float64) []typeDelta {
+	byURL := make(map[string]Driver, len(cur))
+	for _, d := range cur {
+		byURL[d.URL] = d
+	}
+
+	var out []typeDelta
+	for _, od := range old {
+		nd, ok := byURL[od.URL]
+		types := make([]string, 0, len(od.Types)+len(nd.Types))
+		for typ := range od.Types {
+			types = append(types, typ)
+		}
+		for typ := range nd.Types {
+			if _, ok := od.Types[typ]; !ok {
+				types = append(types, typ)
+			}
+		}
+		sort.Strings(types)
+
+		for _, typ := range types {
+			d := typeDelta{Driver: od.Name, Type: typ, Old: od.Types[typ], New: nd.Types[typ]}
+			if d.Old == d.New {
+				continue
+			}
+			used := d.Old.Fixtures > 0 || d.Old.Code > 0
+			switch {
+			case !ok && used:
+				d.Fail = "driver removed"
+			case used && d.New.Fixtures == 0 && d.New.Code == 0:
+				d.Fail = "type removed"
+			case dropped(d.Old.Fixtures, d.New.Fixtures, threshold):
+				d.Fail = "fixtures usage dropped"
+			case dropped(d.Old.Code, d.New.Code, threshold):
+				d.Fail = "code usage dropped"
+			}
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// dropped checks if the value dropped by more than threshold percent.
+func dropped(old, cur int, threshold float64) bool {
+	if cur >= old {
+		return false
+	}
+	return float64(old-cur)*100/float64(old) > threshold
+}
+
+// runDiff compares the previous and the current types reports and prints all the changes.
+// It returns an error if any of the changes is a regression.
+func runDiff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected previous and current reports, got %d files", len(args))
+	}
+	old, err := readReport(args[0])
+	if err != nil {
+		return err
+	}
+	cur, err := readReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	deltas := diffReports(old, cur, *threshold)
+	formatDiffMarkdown(os.Stdout, deltas)
+
+	failed := 0
+	for _, d := range deltas {
+		if d.Fail != "" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("UAST types coverage regressed: %d changes over %.1f%% threshold", failed, *threshold)
+	}
+	return nil
+}
+
+func formatDiffMarkdown(w io.Writer, deltas []typeDelta) {
+	if len(deltas) == 0 {
+		fmt.Fprintln(w, "No changes in UAST types usage.")
+		return
+	}
+	fmt.Fprint(w, "| Driver | Type | Fixtures usage | Code usage | Regression |\n")
+	fmt.Fprint(w, "| :----- | :--- | :------------- | :--------- | :--------- |\n")
+	for _, d := range deltas {
+		fail := "-"
+		if d.Fail != "" {
+			fail = "✗ " + d.Fail
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", d.Driver, d.Type,
+			formatChange(d.Old.Fixtures, d.New.Fixtures),
+			formatChange(d.Old.Code, d.New.Code),
+			fail,
+		)
+	}
+}
+
+// formatChange formats the change of the value between two reports.
+func formatChange(old, cur int) string {
+	if old == cur {
+		return fmt.Sprint(cur)
+	}
+	return fmt.Sprintf("%d → %d (%+d)", old, cur, cur-old)
+}
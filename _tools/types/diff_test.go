@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var testTypes = []uastType{
+	{name: "uast.Identifier", typeName: "Identifier"},
+	{name: "uast.String", typeName: "String"},
+}
+
+func testDriver(name, url string, fixtures, code map[string]int) *driverStats {
+	st := newStats()
+	st.name = name
+	st.url = url
+	for typ, n := range fixtures {
+		st.uastInFixturesCount[typ] = n
+	}
+	for typ, n := range code {
+		st.uastInCodeCount[typ] = n
+	}
+	return st
+}
+
+func TestParseMarkdownTable(t *testing.T) {
+	goDriver := testDriver("Go", "https://github.com/bblfsh/go-driver",
+		map[string]int{"uast.Identifier": 10, "uast.String": 3},
+		map[string]int{"uast.Identifier": 2},
+	)
+	pyDriver := testDriver("Python", "https://github.com/bblfsh/python-driver",
+		map[string]int{"uast.String": 5}, nil,
+	)
+	pyDriver.sync = syncFailed
+
+	buf := bytes.NewBuffer(nil)
+	formatMarkdownTable(buf, []*driverStats{goDriver, pyDriver}, testTypes)
+
+	got, err := parseMarkdownTable(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []Driver{
+		{
+			Name: "Go",
+			URL:  "https://github.com/bblfsh/go-driver",
+			Types: map[string]TypeUsage{
+				"uast:Identifier": {Fixtures: 10, Code: 2},
+				"uast:String":     {Fixtures: 3},
+			},
+		},
+		{
+			Name:  "Python",
+			URL:   "https://github.com/bblfsh/python-driver",
+			Stale: true,
+			Types: map[string]TypeUsage{
+				"uast:Identifier": {},
+				"uast:String":     {Fixtures: 5},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected report:\n%+v\nexpected:\n%+v", got, exp)
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	cases := []struct {
+		cell string
+		exp  TypeUsage
+		err  bool
+	}{
+		{cell: "-", exp: TypeUsage{}},
+		{cell: "7", exp: TypeUsage{Fixtures: 7}},
+		{cell: "7/3", exp: TypeUsage{Fixtures: 7, Code: 3}},
+		{cell: "0/3", exp: TypeUsage{Code: 3}},
+		{cell: "x/3", err: true},
+		{cell: "7/x", err: true},
+	}
+	for _, c := range cases {
+		got, err := parseUsage(c.cell)
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", c.cell)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", c.cell, err)
+		} else if got != c.exp {
+			t.Errorf("%q: got %+v, expected %+v", c.cell, got, c.exp)
+		}
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	const url = "https://github.com/bblfsh/go-driver"
+	report := func(types map[string]TypeUsage) []Driver {
+		return []Driver{{Name: "Go", URL: url, Types: types}}
+	}
+
+	cases := []struct {
+		name string
+		old  []Driver
+		cur  []Driver
+		exp  []typeDelta
+	}{
+		{
+			name: "unchanged",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 10}}),
+			cur:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 10}}),
+		},
+		{
+			name: "type removed",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 10, Code: 1}}),
+			cur:  report(map[string]TypeUsage{"uast:Identifier": {}}),
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:Identifier",
+				Old:  TypeUsage{Fixtures: 10, Code: 1},
+				Fail: "type removed",
+			}},
+		},
+		{
+			name: "driver removed",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 10}}),
+			cur:  nil,
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:Identifier",
+				Old:  TypeUsage{Fixtures: 10},
+				Fail: "driver removed",
+			}},
+		},
+		{
+			name: "type added",
+			old:  report(map[string]TypeUsage{}),
+			cur:  report(map[string]TypeUsage{"uast:String": {Fixtures: 3}}),
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:String",
+				New: TypeUsage{Fixtures: 3},
+			}},
+		},
+		{
+			// 10% drop is exactly the threshold, thus not a regression
+			name: "at threshold",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 100}}),
+			cur:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 90}}),
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:Identifier",
+				Old: TypeUsage{Fixtures: 100},
+				New: TypeUsage{Fixtures: 90},
+			}},
+		},
+		{
+			name: "over threshold",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 100, Code: 10}}),
+			cur:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 89, Code: 10}}),
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:Identifier",
+				Old:  TypeUsage{Fixtures: 100, Code: 10},
+				New:  TypeUsage{Fixtures: 89, Code: 10},
+				Fail: "fixtures usage dropped",
+			}},
+		},
+		{
+			name: "code over threshold",
+			old:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 100, Code: 10}}),
+			cur:  report(map[string]TypeUsage{"uast:Identifier": {Fixtures: 100, Code: 8}}),
+			exp: []typeDelta{{
+				Driver: "Go", Type: "uast:Identifier",
+				Old:  TypeUsage{Fixtures: 100, Code: 10},
+				New:  TypeUsage{Fixtures: 100, Code: 8},
+				Fail: "code usage dropped",
+			}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := diffReports(c.old, c.cur, 10)
+			if !reflect.DeepEqual(got, c.exp) {
+				t.Fatalf("unexpected changes:\n%+v\nexpected:\n%+v", got, c.exp)
+			}
+		})
+	}
+}
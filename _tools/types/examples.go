@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/uastyaml"
+)
+
+// example is a node of UAST type found in driver fixtures.
+type example struct {
+	source     string       // path to the source file of the fixture
+	node       nodes.Object // UAST node of the type
+	start, end uint32       // offsets of the node in the source file
+}
+
+// collectExample records the node as an example of its type,
+// if it is smaller than the one recorded previously.
+// Nodes without valid positional information are ignored.
+func collectExample(driver *driverStats, name string, obj nodes.Object, source string) {
+	start, end, _ := nodePositions(obj)
+	if start == nil || end == nil || end.Offset <= start.Offset {
+		return
+	}
+	if ex := driver.examples[name]; ex != nil && ex.end-ex.start <= end.Offset-start.Offset {
+		return
+	}
+	driver.examples[name] = &example{
+		source: source,
+		node:   obj,
+		start:  start.Offset,
+		end:    end.Offset,
+	}
+}
+
+// snippet returns the source code of the example node.
+func (ex *example) snippet() (string, error) {
+	data, err := ioutil.ReadFile(ex.source)
+	if err != nil {
+		return "", err
+	}
+	if int(ex.end) > len(data) {
+		return "", fmt.Errorf("%s: node ends at %d, after the end of file", ex.source, ex.end)
+	}
+	return string(data[ex.start:ex.end]), nil
+}
+
+func formatExamplesMarkdown(w io.Writer, drivers []*driverStats, uastTypes []uastType) {
+	fmt.Fprint(w, examplesHeader)
+	defer fmt.Fprint(w, footer)
+
+	drs := withFixtures(drivers)
+	for _, typee := range uastTypes {
+		written := false
+		for _, dr := range drs {
+			ex := dr.examples[typee.name]
+			if ex == nil {
+				continue
+			}
+			code, err := ex.snippet()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "unable to read example of %s for %s: %s\n", typee.name, dr.language, err)
+				continue
+			}
+			data, err := uastyaml.Marshal(ex.node)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "unable to encode example of %s for %s: %s\n", typee.name, dr.language, err)
+				continue
+			}
+			if !written {
+				written = true
+				fmt.Fprintf(w, "\n## %s\n", typeLink(typee))
+			}
+			fmt.Fprintf(w, "\n### %s\n\n```%s\n%s\n```\n\n```yaml\n%s\n```\n",
+				driverLink(dr), dr.language, strings.TrimRight(code, "\n"), strings.TrimRight(string(data), "\n"),
+			)
+		}
+	}
+	formatStaleNote(w, drs)
+}
+
+const examplesHeader = `<!-- Code generated by 'make examples' DO NOT EDIT. -->
+# UAST Types Examples
+
+For every [UAST type](semantic-uast.md#types) in every driver the smallest node
+of this type found in driver _fixtures_ (_*.sem.uast_ files) is shown, together
+with the source code it was produced from.
+`
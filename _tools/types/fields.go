@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// typeFields lists paths to all fields of the UAST type.
+// Fields of embedded structs are inlined, and fields of nested structs
+// are listed with a dot-separated path, e.g. Type.Returns for uast.Function.
+func typeFields(rt reflect.Type) []string {
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	var out []string
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Anonymous {
+			out = append(out, typeFields(f.Type)...)
+			continue
+		}
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+		out = append(out, name)
+		for _, sub := range typeFields(f.Type) {
+			out = append(out, name+"."+sub)
+		}
+	}
+	return out
+}
+
+// fieldName returns the name of the UAST node field corresponding to the struct field.
+// It follows the same rules as the SDK: uast tag takes precedence over the json tag.
+func fieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("uast"), ",")[0]
+	if name == "" {
+		name = strings.Split(f.Tag.Get("json"), ",")[0]
+	}
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldValue returns the value of the field with the given path in the node,
+// or nil if it is not set.
+func fieldValue(obj nodes.Object, path string) nodes.Node {
+	var n nodes.Node = obj
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return nil
+		}
+		n = obj[key]
+	}
+	return n
+}
+
+// isEmpty checks if the node holds a zero value.
+func isEmpty(n nodes.Node) bool {
+	switch n := n.(type) {
+	case nil:
+		return true
+	case nodes.Object:
+		return len(n) == 0
+	case nodes.Array:
+		return len(n) == 0
+	case nodes.String:
+		return n == ""
+	case nodes.Bool:
+		return !bool(n)
+	}
+	return false
+}
+
+func formatFieldsMarkdown(w io.Writer, drivers []*driverStats, uastTypes []uastType) {
+	fmt.Fprint(w, fieldsHeader)
+	defer fmt.Fprint(w, footer)
+
+	drs := withFixtures(drivers)
+
+	for _, typee := range uastTypes {
+		if len(typee.fields) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", typeLink(typee))
+		formatMarkdownTableHeader(w, drs)
+		for _, field := range typee.fields {
+			fmt.Fprintf(w, "| %s |", field)
+			for _, dr := range drs {
+				total := dr.uastInFixturesCount[typee.name]
+				if total == 0 {
+					fmt.Fprintf(w, " - |")
+					continue
+				}
+				set := dr.fieldsInFixtures[typee.name+"."+field]
+				fmt.Fprintf(w, " %s |", fieldPercent(set, total))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	formatStaleNote(w, drs)
+}
+
+// fieldPercent formats the percent of nodes with the field set. It is rounded down,
+// thus 100% means the field is always set, while fields set on a few nodes only
+// are reported as <1% rather than 0%, which means the field is never set.
+func fieldPercent(set, total int) string {
+	p := set * 100 / total
+	if p == 0 && set > 0 {
+		return "<1%"
+	}
+	return fmt.Sprintf("%d%%", p)
+}
+
+const fieldsHeader = `<!-- Code generated by 'make fields' DO NOT EDIT. -->
+# UAST Type Fields
+
+For every field of every [UAST type](semantic-uast.md#types)
+in every driver the following value is reported:
+ - _fields population_ - percent of nodes of this type in driver _fixtures_ (_*.sem.uast_ files) that have this field set to a non-empty value
+
+A dash is reported if the driver fixtures have no nodes of this type.
+Values are rounded down, _<1%_ is reported if the field is set on a few nodes only.
+`
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DriverHistory is a machine-readable report about UAST types usage by all releases of a driver.
+type DriverHistory struct {
+	Language string
+	Name     string
+	URL      string
+	Releases []Release // sorted from the oldest to the latest release
+}
+
+// Release is a machine-readable report about UAST types usage by a single driver release.
+type Release struct {
+	Version  string
+	Skipped  bool `json:",omitempty"` // release has no fixtures
+	Fixtures int
+	Types    map[string]TypeUsage // keyed by UAST type name, e.g. uast:Identifier
+}
+
+// typesUsed returns the number of UAST types used in the release fixtures
+// and the total number of UAST nodes in them.
+func (r Release) typesUsed() (types, nodes int) {
+	for _, u := range r.Types {
+		if u.Fixtures > 0 {
+			types++
+			nodes += u.Fixtures
+		}
+	}
+	return types, nodes
+}
+
+// runHistory analyzes every release of all drivers and writes the results
+// either to stdout or to given files.
+func runHistory(drivers []*driverStats, uastTypes []uastType, outFiles []string) error {
+	ctx := context.TODO()
+	hist := make([]DriverHistory, 0, len(drivers))
+	var failed []string
+	for _, dr := range drivers {
+		if dr.sync == syncFailed {
+			// already reported as a sync failure
+			fmt.Fprintf(os.Stderr, "%s failed to sync, skipping its releases\n", dr.language)
+			continue
+		}
+		h, err := driverHistory(ctx, dr, uastTypes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to analyze releases of %s: %s\n", dr.language, err)
+			failed = append(failed, fmt.Sprintf("%s: %s", dr.language, err))
+			continue
+		}
+		hist = append(hist, h)
+	}
+
+	if len(outFiles) == 0 {
+		formatHistoryMarkdown(os.Stdout, hist)
+	}
+	for _, fname := range outFiles {
+		if err := writeHistoryFile(fname, hist); err != nil {
+			return err
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("failed to analyze releases of %d of %d drivers:\n%s",
+			len(failed), len(drivers), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+// driverHistory checks out every release of the driver and analyzes it,
+// assuming the driver is cloned. The clone is switched back to the current branch afterwards,
+// or to the current revision if HEAD is detached.
+func driverHistory(ctx context.Context, dr *driverStats, uastTypes []uastType) (DriverHistory, error) {
+	h := DriverHistory{
+		Language: dr.language,
+		Name:     dr.name,
+		URL:      dr.url,
+	}
+	vers, err := dr.driver.Versions(ctx)
+	if err != nil {
+		return h, err
+	}
+	fmt.Fprintf(os.Stderr, "%d releases of %s found\n", len(vers), dr.language)
+
+	repoPath := filepath.Join(reposRootPath, dr.path)
+	if !*skipUpdate {
+		if err := git(repoPath, "fetch", "--tags", "origin"); err != nil {
+			return h, err
+		}
+	}
+	head, err := gitOutput(repoPath, "symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil {
+		// detached HEAD
+		head, err = gitOutput(repoPath, "rev-parse", "HEAD")
+		if err != nil {
+			return h, err
+		}
+	}
+	defer func() {
+		if err := git(repoPath, "checkout", "-q", head); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to restore %s: %s\n", repoPath, err)
+		}
+	}()
+
+	// versions are sorted from the latest to the oldest
+	for i := len(vers) - 1; i >= 0; i-- {
+		ver := vers[i].String()
+		if err := git(repoPath, "checkout", "-q", "v"+ver); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to checkout v%s of %s, skipping: %s\n", ver, dr.language, err)
+			continue
+		}
+		st := newDriverStats(dr.driver)
+		if err := analyzeFixtures(st, uastTypes); err != nil {
+			return h, err
+		}
+		if err := analyzeCode(st, uastTypes); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to analyze code of %s v%s: %s\n", dr.language, ver, err)
+		}
+		d := newDriverReport(st, uastTypes)
+		h.Releases = append(h.Releases, Release{
+			Version:  ver,
+			Skipped:  d.Skipped,
+			Fixtures: d.Fixtures,
+			Types:    d.Types,
+		})
+	}
+	return h, nil
+}
+
+// writeHistoryFile writes the history report to a file, choosing the format based on the file extension.
+func writeHistoryFile(fname string, hist []DriverHistory) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	switch filepath.Ext(fname) {
+	case ".json":
+		data, err := json.MarshalIndent(hist, "", "\t")
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+	case ".md":
+		fallthrough
+	default:
+		formatHistoryMarkdown(w, hist)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatHistoryMarkdown(w io.Writer, hist []DriverHistory) {
+	fmt.Fprint(w, historyHeader)
+	defer fmt.Fprint(w, footer)
+
+	for _, h := range hist {
+		if len(h.Releases) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## [%s](%s)\n\n", h.Name, h.URL)
+		fmt.Fprint(w, "| Release | Fixtures | UAST types | UAST nodes |\n")
+		fmt.Fprint(w, "| :------ | :------- | :--------- | :--------- |\n")
+		var prevTypes, prevNodes int
+		for i, r := range h.Releases {
+			types, nodes := r.typesUsed()
+			fmt.Fprintf(w, "| [v%s](%s/releases/tag/v%s) | %d | %s | %s |\n",
+				r.Version, h.URL, r.Version, r.Fixtures,
+				withDelta(types, prevTypes, i == 0),
+				withDelta(nodes, prevNodes, i == 0),
+			)
+			prevTypes, prevNodes = types, nodes
+		}
+	}
+}
+
+// withDelta formats the value together with its change since the previous release.
+func withDelta(cur, prev int, first bool) string {
+	if first || cur == prev {
+		return fmt.Sprint(cur)
+	}
+	return fmt.Sprintf("%d (%+d)", cur, cur-prev)
+}
+
+const historyHeader = `<!-- Code generated by 'make types-history' DO NOT EDIT. -->
+# UAST Types History
+
+For every release of every driver the following values are reported:
+ - _fixtures_ - number of fixture files in the release
+ - _UAST types_ - number of distinct [UAST types](semantic-uast.md#types) used in the release _fixtures_ (_*.sem.uast_ files)
+ - _UAST nodes_ - total number of UAST nodes in the release _fixtures_
+
+Changes since the previous release are reported in parentheses.
+`
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const lockHeader = "# Code generated by 'make lock' DO NOT EDIT.\n" +
+	"# Pinned revisions of drivers used to generate the documentation.\n"
+
+// readLock reads pinned revisions of drivers from the lockfile.
+// Revisions are keyed by the driver repository URL.
+func readLock(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lock := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected repository URL and revision", path, line)
+		}
+		lock[fields[0]] = fields[1]
+	}
+	return lock, sc.Err()
+}
+
+// writeLock records current revisions of all drivers to the lockfile.
+func writeLock(path string, drivers []*driverStats) error {
+	lines := make([]string, 0, len(drivers))
+	for _, d := range drivers {
+		rev, err := gitOutput(filepath.Join(reposRootPath, d.path), "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, d.url+" "+rev+"\n")
+	}
+	sort.Strings(lines)
+
+	buf := bytes.NewBufferString(lockHeader)
+	for _, line := range lines {
+		buf.WriteString(line)
+	}
+	fmt.Fprintf(os.Stderr, "%d driver revisions written to %s\n", len(lines), path)
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// checkLocked checks that all the drivers are pinned in the lockfile,
+// so none of them is silently left out of the reports.
+func checkLocked(drivers []*driverStats, lock map[string]string) error {
+	var missing []string
+	for _, d := range drivers {
+		if _, ok := lock[d.url]; !ok {
+			missing = append(missing, d.url)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%d drivers are not in the lockfile, run 'make lock' to pin them:\n%s",
+			len(missing), strings.Join(missing, "\n"))
+	}
+	return nil
+}
+
+// checkoutLockedAll clones repos to path in local FS, if not yet present,
+// and checks out revisions pinned in the lockfile for each of them.
+func checkoutLockedAll(drivers []*driverStats, lock map[string]string) error {
+	fmt.Fprintf(os.Stderr, "checking out %d pinned drivers to %s\n", len(drivers), reposRootPath)
+	return syncAll(drivers, func(d *driverStats) (syncStatus, error) {
+		return checkoutLocked(d, lock[d.url])
+	})
+}
+
+// checkoutLocked checks out the given revision of the driver,
+// fetching it from the remote only if it is not available locally.
+func checkoutLocked(d *driverStats, rev string) (syncStatus, error) {
+	status := syncUpdated
+	repoPath := filepath.Join(reposRootPath, d.path)
+	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "%s does not exist, cloning from %s\n", repoPath, d.url)
+		if err := git(reposRootPath, "clone", d.url+".git"); err != nil {
+			return syncFailed, err
+		}
+		status = syncCloned
+	} else if err != nil {
+		return syncFailed, err
+	}
+
+	if err := git(repoPath, "cat-file", "-e", rev+"^{commit}"); err != nil {
+		fmt.Fprintf(os.Stderr, "%s is not in %s, fetching\n", rev, repoPath)
+		if err := git(repoPath, "fetch", "origin"); err != nil {
+			return syncFailed, err
+		}
+	}
+	if err := git(repoPath, "checkout", "-q", rev); err != nil {
+		return syncFailed, err
+	}
+	return status, nil
+}
@@ -3,9 +3,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -13,29 +19,42 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
-	"regexp"
+	"runtime"
 	"strings"
 	"sync"
 
 	"github.com/bblfsh/sdk/v3/driver/manifest/discovery"
 	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/uastyaml"
 
 	_ "net/http/pprof"
 )
 
 const (
-	fixtureDir = "fixtures"
-	fixtureExt = ".sem.uast"
-	goDocURL   = "https://godoc.org/github.com/bblfsh/sdk/uast#"
-	pprofAddr  = "localhost:6060"
+	fixtureDir    = "fixtures"
+	fixtureExt    = ".sem.uast"
+	nativeExt     = ".native"
+	normalizerDir = "driver/normalizer"
+	uastPkg       = "github.com/bblfsh/sdk/v3/uast"
+	goDocURL      = "https://godoc.org/github.com/bblfsh/sdk/uast#"
+	pprofAddr     = "localhost:6060"
 )
 
 var (
 	reposRootPath = filepath.Join(".", "_drivers")
-	uastNodeRe    = regexp.MustCompile("uast:[^\"]*")
 
 	pprof      = flag.Bool("pprof", false, "Start a pprof profiler HTTP service at "+pprofAddr)
 	skipUpdate = flag.Bool("skip", false, "skip git clone or pull")
+	lockFile   = flag.String("lock", "_drivers.lock", "lockfile with pinned revisions of drivers")
+	locked     = flag.Bool("locked", false, "checkout revisions of drivers pinned in the lockfile instead of git pull")
+	updateLock = flag.Bool("update-lock", false, "git pull drivers, record their revisions to the lockfile and exit")
+	history    = flag.Bool("history", false, "report types usage for every release of drivers")
+	diff       = flag.Bool("diff", false, "compare previous and current reports given as arguments and fail on regressions")
+	threshold  = flag.Float64("threshold", 0, "max drop of a usage count in percent, allowed by -diff")
+	workers    = flag.Int("workers", runtime.NumCPU(), "number of fixture files to analyze in parallel")
+	cacheDir   = flag.String("cache", filepath.Join(reposRootPath, ".cache", "types"), "directory to cache analysis results of fixtures in, empty to disable")
+	report     = flag.String("report", "types", "Markdown report to generate: types, fields, native, examples or positions")
 )
 
 func main() {
@@ -50,44 +69,129 @@ func main() {
 		}()
 	}
 
-	if err := run(); err != nil {
+	if *diff {
+		if err := runDiff(flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := run(flag.Args()); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run() error {
+func run(outFiles []string) error {
 	drivers, err := listDrivers()
 	if err != nil {
 		return fmt.Errorf("failed to list drivers: %s", err)
 	}
 
-	if !*skipUpdate {
-		if err := maybeCloneOrPullAll(drivers); err != nil {
-			return fmt.Errorf("failed to pull driver repos: %s", err)
+	if *locked && *updateLock {
+		return fmt.Errorf("-locked and -update-lock cannot be used together")
+	}
+	// failures to sync are reported after the analysis,
+	// drivers that failed are marked as stale in the report
+	var syncErr error
+	if *locked {
+		lock, err := readLock(*lockFile)
+		if err != nil {
+			return fmt.Errorf("failed to read lockfile: %s", err)
+		}
+		if err := checkLocked(drivers, lock); err != nil {
+			return err
 		}
+		syncErr = checkoutLockedAll(drivers, lock)
+	} else if !*skipUpdate {
+		syncErr = maybeCloneOrPullAll(drivers)
+	}
+	if *updateLock {
+		if syncErr != nil {
+			return fmt.Errorf("failed to pull driver repos: %s", syncErr)
+		}
+		return writeLock(*lockFile, drivers)
 	}
 
-	uastTypes := findUASTTypesInSDK()
-	for _, driver := range drivers {
-		if err := analyzeFixtures(driver); err != nil {
+	uastTypes, err := findUASTTypesInSDK()
+	if err != nil {
+		return fmt.Errorf("failed to find UAST types in SDK: %s", err)
+	}
+	if *history {
+		if err := runHistory(drivers, uastTypes, outFiles); err != nil {
+			if syncErr != nil {
+				return fmt.Errorf("%s\n%s", syncErr, err)
+			}
 			return err
 		}
-		analyzeCode(driver, uastTypes)
+		return syncErr
+	}
+	if err := analyzeAllFixtures(drivers, uastTypes, *workers); err != nil {
+		return err
+	}
+	for _, driver := range drivers {
+		if err := analyzeCode(driver, uastTypes); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to analyze code of %s: %s\n", driver.language, err)
+		}
 	}
 
-	formatMarkdownTable(drivers, uastTypes)
-	return nil
+	if len(outFiles) == 0 {
+		if err := writeMarkdown(os.Stdout, drivers, uastTypes); err != nil {
+			return err
+		}
+	}
+	for _, fname := range outFiles {
+		if err := writeFile(fname, drivers, uastTypes); err != nil {
+			return err
+		}
+	}
+	return syncErr
 }
 
 type driverStats struct {
-	url                 string         // driver repository URL
-	name                string         // human-readable language name
-	language            string         // language identifier
-	path                string         // path in local FS to the driver source code clone
-	skip                bool           // skip including the driver to the final report
-	fixtureCount        int            // number of fixture files for a driver
-	uastInFixturesCount map[string]int // number of times UAST type used in fixtures
-	uastInCodeCount     map[string]int // number of times UAST type used in code
+	driver              discovery.Driver // driver manifest, as discovered on-line
+	url                 string           // driver repository URL
+	name                string           // human-readable language name
+	language            string           // language identifier
+	path                string           // path in local FS to the driver source code clone
+	skip                bool             // skip including the driver to the final report
+	sync                syncStatus       // status of the driver repository synchronization
+	syncErr             error            // error occurred during the synchronization, if any
+	fixtureCount        int              // number of fixture files for a driver
+	uastInFixturesCount map[string]int   // number of times UAST type used in fixtures
+	uastInCodeCount     map[string]int   // number of times UAST type used in code
+	fieldsInFixtures    map[string]int   // number of times UAST type field is set in fixtures
+
+	nativeInFixtures map[string]int      // number of times native type used in native fixtures
+	nativeInSemantic map[string]int      // number of times native type left unmapped in fixtures
+	nativeMappedTo   map[string][]string // UAST types native type is mapped to in code
+
+	examples map[string]*example // the smallest node of UAST type in fixtures
+
+	positionsChecked int      // number of positions checked in fixtures
+	positionErrors   []string // inconsistencies of positions found in fixtures
+}
+
+// newDriverStats creates empty stats for the given driver.
+func newDriverStats(l discovery.Driver) *driverStats {
+	st := newStats()
+	st.driver = l
+	st.name = l.Name
+	st.language = l.Language
+	st.url = l.RepositoryURL()
+	st.path = st.url[strings.LastIndex(st.url, "/"):]
+	return st
+}
+
+// newStats creates empty stats, not associated with any driver.
+func newStats() *driverStats {
+	return &driverStats{
+		uastInFixturesCount: make(map[string]int),
+		uastInCodeCount:     make(map[string]int),
+		fieldsInFixtures:    make(map[string]int),
+		nativeInFixtures:    make(map[string]int),
+		nativeInSemantic:    make(map[string]int),
+		nativeMappedTo:      make(map[string][]string),
+		examples:            make(map[string]*example),
+	}
 }
 
 // listDrivers lists all available drivers.
@@ -107,23 +211,39 @@ func listDrivers() ([]*driverStats, error) {
 		if !l.ForCurrentSDK() || l.InDevelopment() {
 			continue
 		}
-		drivers = append(drivers, &driverStats{
-			name:                l.Name,
-			language:            l.Language,
-			url:                 l.RepositoryURL(),
-			path:                l.RepositoryURL()[strings.LastIndex(l.RepositoryURL(), "/"):],
-			uastInFixturesCount: make(map[string]int),
-			uastInCodeCount:     make(map[string]int),
-		})
+		drivers = append(drivers, newDriverStats(l))
 	}
 	fmt.Fprintf(os.Stderr, "%d drivers available on-line\n", len(langs))
 	return drivers, nil
 }
 
-// maybeCloneOrPullAll either clones repos to path in local FS or, if already preset,
-// pulls the latest master for each of them.
-func maybeCloneOrPullAll(drivers []*driverStats) error {
-	fmt.Fprintf(os.Stderr, "cloning %d drivers to %s\n", len(drivers), reposRootPath)
+// syncStatus is a status of synchronization of the driver repository.
+type syncStatus int
+
+const (
+	syncSkipped syncStatus = iota // repository was not synchronized, local data is used as-is
+	syncCloned                    // repository was cloned
+	syncUpdated                   // repository was updated to the requested revision
+	syncFailed                    // repository failed to synchronize, local data is stale
+)
+
+func (s syncStatus) String() string {
+	switch s {
+	case syncSkipped:
+		return "skipped"
+	case syncCloned:
+		return "cloned"
+	case syncUpdated:
+		return "updated"
+	case syncFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("syncStatus(%d)", int(s))
+}
+
+// syncAll synchronizes all driver repos to path in local FS with the given function,
+// and records the status for each of them. It returns an error listing all the failures.
+func syncAll(drivers []*driverStats, fn func(d *driverStats) (syncStatus, error)) error {
 	err := os.MkdirAll(reposRootPath, os.ModePerm)
 	if err != nil {
 		return err
@@ -139,191 +259,419 @@ func maybeCloneOrPullAll(drivers []*driverStats) error {
 		go func() {
 			throttle <- 1
 			defer func() { <-throttle; wg.Done() }()
-			maybeCloneOrPull(driver)
+			driver.sync, driver.syncErr = fn(driver)
+			if driver.syncErr != nil {
+				driver.sync = syncFailed
+				fmt.Fprintf(os.Stderr, "failed to sync %s: %s\n", driver.url, driver.syncErr)
+			}
 		}()
 	}
 	wg.Wait()
+
+	var failed []string
+	for _, d := range drivers {
+		if d.sync == syncFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", d.language, d.syncErr))
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("%d of %d drivers failed to sync:\n%s",
+			len(failed), len(drivers), strings.Join(failed, "\n"))
+	}
 	return nil
 }
 
-func maybeCloneOrPull(d *driverStats) {
+// maybeCloneOrPullAll either clones repos to path in local FS or, if already preset,
+// pulls the latest master for each of them.
+func maybeCloneOrPullAll(drivers []*driverStats) error {
+	fmt.Fprintf(os.Stderr, "cloning %d drivers to %s\n", len(drivers), reposRootPath)
+	return syncAll(drivers, maybeCloneOrPull)
+}
+
+func maybeCloneOrPull(d *driverStats) (syncStatus, error) {
 	repoPath := filepath.Join(reposRootPath, d.path)
 	_, err := os.Stat(repoPath)
 	if os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "%s does not exist, cloning from %s\n", repoPath, d.url)
-		cmd := exec.Command("git", "clone", d.url+".git")
-		cmd.Dir = reposRootPath
-		err = cmd.Run()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to git clone %s\n", d.url)
+		if err := git(reposRootPath, "clone", d.url+".git"); err != nil {
+			return syncFailed, err
 		}
+		return syncCloned, nil
 	} else if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to read %q: %s\n", repoPath, err)
+		return syncFailed, err
 	}
 
 	fmt.Fprintf(os.Stderr, "%s dir exists, will git pull instead\n", repoPath)
-	cmd := exec.Command("git", "pull", "origin", "master")
-	cmd.Dir = repoPath
-	err = cmd.Run()
+	if err := git(repoPath, "pull", "origin", "master"); err != nil {
+		return syncFailed, err
+	}
+	return syncUpdated, nil
+}
+
+// git runs a git command in the given directory.
+func git(dir string, args ...string) error {
+	_, err := gitOutput(dir, args...)
+	return err
+}
+
+// gitOutput runs a git command in the given directory and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	out = bytes.TrimSpace(out)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed git pull %s\n", d.url)
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, out)
 	}
+	return string(out), nil
 }
 
 type uastType struct {
-	name string
-	// TODO decide what else is needed for analyzeCode()
-	// or replace struct with string
+	name     string   // qualified name, as used in the report, e.g. uast.Identifier
+	pkgPath  string   // import path of the Go package declaring the type
+	typeName string   // name of the Go type
+	fields   []string // paths to all fields of the type, see typeFields
 }
 
-// isUsedIn checks if current UAST type is used in the given package.
-func (u *uastType) isUsedIn() bool {
-	// TODO implement for analyzeCode()
-	return false
+// usesIn counts the references to current UAST type in the given file.
+func (u *uastType) usesIn(f *ast.File) int {
+	name := importName(f, u.pkgPath)
+	if name == "" {
+		return 0
+	}
+	n := 0
+	ast.Inspect(f, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != u.typeName {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+			n++
+		}
+		return true
+	})
+	return n
 }
 
-// findUASTTypesInSDK finds all types from SDK.
-func findUASTTypesInSDK() []uastType {
-	var out []uastType
-	// TODO: either parse code of the package, iterate all structs
-	// or expose all registered types though SDK and use it here
-	types := []interface{}{
-		uast.Position{},
-		uast.Positions{},
-		uast.Identifier{},
-		uast.String{},
-		uast.Bool{},
-		uast.QualifiedIdentifier{},
-		uast.Comment{},
-		uast.Group{},
-		uast.FunctionGroup{},
-		uast.Block{},
-		uast.Alias{},
-		uast.Import{},
-		uast.RuntimeImport{},
-		uast.RuntimeReImport{},
-		uast.InlineImport{},
-		uast.Argument{},
-		uast.FunctionType{},
-		uast.Function{},
-	}
-	for _, typee := range types {
-		out = append(out, uastType{reflect.TypeOf(typee).String()})
+// importName returns the name under which the package is imported in the file,
+// or an empty string if the file does not import it.
+func importName(f *ast.File, pkgPath string) string {
+	for _, imp := range f.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != pkgPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return pkgPath[strings.LastIndex(pkgPath, "/")+1:]
 	}
-	fmt.Fprintf(os.Stderr, "%d uast:* types found in SDK\n", len(out))
-	return out
+	return ""
 }
 
-// analyzeFixtures goes though all fixtures, assuming the driver is cloned.
-// It updates given driverStats with results.
-func analyzeFixtures(driver *driverStats) error {
-	fixDir := filepath.Join(reposRootPath, driver.path, fixtureDir)
-	fixtureFiles, err := lsDir(fixDir)
-	if os.IsNotExist(err) {
-		driver.skip = true
-		return nil
-	} else if err != nil {
-		return err
+// findUASTTypesInSDK finds all types from SDK.
+// It parses the code of the SDK package and collects all the types
+// registered with uast.RegisterPackage.
+func findUASTTypesInSDK() ([]uastType, error) {
+	pkg, err := build.Import(uastPkg, ".", 0)
+	if err != nil {
+		return nil, err
 	}
-	driver.fixtureCount += len(fixtureFiles)
 
-	for _, file := range fixtureFiles {
-		if !strings.HasSuffix(file.Name(), fixtureExt) {
-			continue
+	var registered []reflect.Type
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
 		}
-
-		data, err := ioutil.ReadFile(filepath.Join(fixDir, file.Name()))
+		ast.Inspect(f, func(n ast.Node) bool {
+			if err != nil {
+				return false
+			}
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			if fnc, ok := call.Fun.(*ast.Ident); !ok || fnc.Name != "RegisterPackage" {
+				return true
+			}
+			for _, arg := range call.Args[1:] {
+				lit, ok := arg.(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				id, ok := lit.Type.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				rt, ok := uast.LookupType(uast.NS + ":" + id.Name)
+				if !ok {
+					err = fmt.Errorf("%s is not registered in SDK", id.Name)
+					return false
+				}
+				registered = append(registered, rt)
+			}
+			return false
+		})
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "unable to read %q, skipping\n", file.Name())
+			return nil, err
+		}
+	}
+
+	// types embedded into other types, like uast.GenNode, never appear as nodes
+	embedded := make(map[reflect.Type]bool)
+	for _, rt := range registered {
+		if rt.Kind() != reflect.Struct {
 			continue
 		}
-		for _, line := range strings.Split(string(data), "\n") {
-			if !strings.Contains(line, "uast:") {
-				continue
-			}
-			for _, uastType := range uastNodeRe.FindAllString(line, -1) {
-				driver.uastInFixturesCount[strings.Replace(uastType, ":", ".", 1)]++
+		for i := 0; i < rt.NumField(); i++ {
+			if f := rt.Field(i); f.Anonymous {
+				embedded[f.Type] = true
 			}
 		}
 	}
-	return nil
+
+	var out []uastType
+	for _, rt := range registered {
+		if embedded[rt] {
+			continue
+		}
+		out = append(out, uastType{
+			name:     rt.String(),
+			pkgPath:  rt.PkgPath(),
+			typeName: rt.Name(),
+			fields:   typeFields(rt),
+		})
+	}
+	fmt.Fprintf(os.Stderr, "%d uast:* types found in SDK\n", len(out))
+	return out, nil
 }
 
-// lsDir lists all files in the given dir.
-// It does not use ioutil.ReadDir as we do not care about files order.
-func lsDir(dir string) ([]os.FileInfo, error) {
-	fmt.Fprintf(os.Stderr, "reading %s/*%s files\n", dir, fixtureExt)
-	f, err := os.Open(dir)
+// analyzeFixtures goes though all fixtures, assuming the driver is cloned.
+// It updates given driverStats with results.
+func analyzeFixtures(driver *driverStats, uastTypes []uastType) error {
+	return analyzeAllFixtures([]*driverStats{driver}, uastTypes, *workers)
+}
+
+// analyzeFixture analyzes a single fixture file and returns the results as new stats.
+// The buffer is used to read the file and can be reused between calls.
+// If the cache is not nil, results are loaded from it, or stored to it after the analysis.
+func analyzeFixture(path string, schema map[string]uastType, buf *bytes.Buffer, cache *fixtureCache) (*driverStats, bool, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	files, err := f.Readdir(-1)
+	buf.Reset()
+	_, err = buf.ReadFrom(f)
 	f.Close()
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	var key string
+	if cache != nil {
+		key, err = cache.key(path, buf.Bytes())
+		if err != nil {
+			return nil, false, err
+		}
+		if st := cache.load(key, path); st != nil {
+			return st, true, nil
+		}
+	}
+
+	root, err := uastyaml.Unmarshal(buf.Bytes())
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to decode: %s", err)
 	}
-	return files, nil
+
+	st := newStats()
+	if strings.HasSuffix(path, nativeExt) {
+		countNativeTypes(root, st.nativeInFixtures)
+	} else {
+		source := strings.TrimSuffix(path, fixtureExt)
+		countTypes(root, st, schema, source)
+		if *report == "positions" {
+			// requires reading the source files, thus only done when needed
+			validatePositions(root, source, st)
+		}
+	}
+	if cache != nil {
+		if err := cache.store(key, st); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to cache results for %q: %s\n", path, err)
+		}
+	}
+	return st, false, nil
+}
+
+// countTypes walks the tree and counts all nodes of UAST types in it,
+// as well as the fields of those nodes that are set. It also collects
+// the smallest node of each type as an example, see collectExample.
+// Counts are keyed by the qualified Go type name, e.g. uast.Identifier.
+func countTypes(root nodes.Node, driver *driverStats, schema map[string]uastType, source string) {
+	nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+		typ := uast.TypeOf(n)
+		if typ == "" {
+			return true
+		} else if !strings.HasPrefix(typ, uast.NS+":") {
+			// native node that was left unmapped by the normalizer
+			driver.nativeInSemantic[typ]++
+			return true
+		}
+		name := strings.Replace(typ, ":", ".", 1)
+		driver.uastInFixturesCount[name]++
+		obj := n.(nodes.Object)
+		for _, field := range schema[name].fields {
+			if !isEmpty(fieldValue(obj, field)) {
+				driver.fieldsInFixtures[name+"."+field]++
+			}
+		}
+		collectExample(driver, name, obj, source)
+		return true
+	})
+}
+
+// lsDir lists all files in the given dir, sorted by name.
+func lsDir(dir string) ([]os.FileInfo, error) {
+	fmt.Fprintf(os.Stderr, "reading %s/*%s files\n", dir, fixtureExt)
+	return ioutil.ReadDir(dir)
 }
 
 // analyzeCode checks if any of the types are used by
 // this driver's package, though analyzing it's AST.
-func analyzeCode(driver *driverStats, uasts []uastType) {
-	// TODO:
-	// load package
-	// for _, typee := range uasts {
-	//   if typee.isUsedIn(package) {
-	//     driver.codeUast[typee]++
-	//   }
-	// }
-}
+// It updates given driverStats with results.
+func analyzeCode(driver *driverStats, uasts []uastType) error {
+	dir := filepath.Join(reposRootPath, driver.path, normalizerDir)
+	fmt.Fprintf(os.Stderr, "reading %s/*.go files\n", dir)
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return err
+	}
 
-func formatMarkdownTable(drivers []*driverStats, uastTypes []uastType) {
-	fmt.Print(header)
-	defer fmt.Print(footer)
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(pkg.GoFiles))
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	// types in mappings may be defined as constants in any file of the package
+	consts := stringConsts(files)
+	for _, f := range files {
+		for _, typee := range uasts {
+			if n := typee.usesIn(f); n > 0 {
+				driver.uastInCodeCount[typee.name] += n
+			}
+		}
+		findMappings(f, consts, driver)
+	}
+	return nil
+}
 
-	allDrivers := len(drivers)
-	drs := drivers[:0] // filter drivers \wo fixtures
+// withFixtures filters out drivers without fixtures.
+func withFixtures(drivers []*driverStats) []*driverStats {
+	var out []*driverStats
 	for _, x := range drivers {
 		if !x.skip {
-			drs = append(drs, x)
+			out = append(out, x)
 		}
 	}
-	fmt.Fprintf(os.Stderr, "only %d drivers has fixtures, out of %d\n", len(drs), allDrivers)
+	return out
+}
 
-	formatMarkdownTableHeader(drs)
+// findUnusedTypes returns the types which are not used by any of the drivers,
+// neither in fixtures nor in code.
+func findUnusedTypes(drivers []*driverStats, uastTypes []uastType) []uastType {
+	var out []uastType
 	for _, typee := range uastTypes {
-		// %25s produces nice ASCII result
-		fmt.Printf("| [%s](%s) |",
-			strings.Replace(typee.name, ".", ":", 1),
-			goDocURL+typee.name[strings.IndexRune(typee.name, '.')+1:],
-		)
+		used := false
+		for _, dr := range drivers {
+			if dr.uastInFixturesCount[typee.name] > 0 || dr.uastInCodeCount[typee.name] > 0 {
+				used = true
+				break
+			}
+		}
+		if !used {
+			out = append(out, typee)
+		}
+	}
+	return out
+}
+
+func formatMarkdownTable(w io.Writer, drivers []*driverStats, uastTypes []uastType) {
+	fmt.Fprint(w, header)
+	defer fmt.Fprint(w, footer)
+
+	drs := withFixtures(drivers)
+	fmt.Fprintf(os.Stderr, "only %d drivers has fixtures, out of %d\n", len(drs), len(drivers))
+
+	formatMarkdownTableHeader(w, drs)
+	for _, typee := range uastTypes {
+		fmt.Fprintf(w, "| %s |", typeLink(typee))
 		for _, dr := range drs {
 			if dr.uastInCodeCount[typee.name] > 0 {
-				fmt.Printf(" %d/%d |",
+				fmt.Fprintf(w, " %d/%d |",
 					dr.uastInFixturesCount[typee.name],
 					dr.uastInCodeCount[typee.name],
 				)
 			} else if v := dr.uastInFixturesCount[typee.name]; v > 0 {
-				fmt.Printf(" %d |", v)
+				fmt.Fprintf(w, " %d |", v)
 			} else {
-				fmt.Printf(" - |")
+				fmt.Fprintf(w, " - |")
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
+	}
+	formatStaleNote(w, drs)
+
+	unused := findUnusedTypes(drs, uastTypes)
+	fmt.Fprintf(os.Stderr, "%d uast:* types are not used by any driver\n", len(unused))
+	if len(unused) == 0 {
+		return
+	}
+	fmt.Fprint(w, unusedHeader)
+	for _, typee := range unused {
+		fmt.Fprintf(w, " - %s\n", typeLink(typee))
 	}
 }
 
-func formatMarkdownTableHeader(drivers []*driverStats) {
-	fmt.Printf("|%25s|", "")
+// typeLink returns a Markdown link to the documentation of UAST type.
+func typeLink(typee uastType) string {
+	return fmt.Sprintf("[%s](%s)", uastName(typee), goDocURL+typee.typeName)
+}
+
+// driverLink returns a Markdown link to the driver repository,
+// marking drivers with stale data.
+func driverLink(dr *driverStats) string {
+	link := fmt.Sprintf("[%s](%s)", dr.name, dr.url)
+	if dr.sync == syncFailed {
+		link += " (stale)"
+	}
+	return link
+}
+
+// formatStaleNote explains the stale mark, if any of the drivers has it.
+func formatStaleNote(w io.Writer, drivers []*driverStats) {
+	for _, dr := range drivers {
+		if dr.sync == syncFailed {
+			fmt.Fprint(w, staleNote)
+			return
+		}
+	}
+}
+
+func formatMarkdownTableHeader(w io.Writer, drivers []*driverStats) {
+	fmt.Fprintf(w, "|%25s|", "")
 	for _, dr := range drivers {
 		// %5s produces nice ASCII result
-		fmt.Printf(" [%s](%s) |", dr.name, dr.url)
+		fmt.Fprintf(w, " %s |", driverLink(dr))
 	}
-	fmt.Print("\n| :---------------------- |")
+	fmt.Fprint(w, "\n| :---------------------- |")
 	for range drivers {
-		fmt.Printf(" :-- |")
+		fmt.Fprintf(w, " :-- |")
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 const header = `<!-- Code generated by 'make types' DO NOT EDIT. -->
@@ -332,13 +680,24 @@ const header = `<!-- Code generated by 'make types' DO NOT EDIT. -->
 For every [UAST type](semantic-uast.md#types)
 in every driver the following two values are reported:
  - _fixtures usage_  - number of times this type was used in driver _fixtures_ (_*.sem.uast_ files)
- - _code usage_ - number of times this type was usind in the driver mapping DSL code (_normalizer.go_ file)
+ - _code usage_ - number of times this type was used in the driver mapping DSL code (_normalizer_ package)
 
 The format is _fixtures usage_ / _code usage_ in case _code usage_ is not zero.
 Otherwise, only _fixture usage_ is report.
 
 `
 
+const unusedHeader = `
+## Unused types
+
+The following types are defined in the SDK, but are not used by any driver:
+
+`
+
+const staleNote = `
+_(stale)_ - driver repository failed to update, the data may be outdated.
+`
+
 const footer = `
 **Don't see your favorite AST construct represented? [Help us!](../join-the-community.md)**
 `
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+)
+
+// countNativeTypes walks the native AST and counts all nodes of each type in it.
+func countNativeTypes(root nodes.Node, counts map[string]int) {
+	nodes.WalkPreOrder(root, func(n nodes.Node) bool {
+		if typ := uast.TypeOf(n); typ != "" {
+			counts[typ]++
+		}
+		return true
+	})
+}
+
+// findMappings finds all the native types mapped to UAST types in the driver code,
+// either with MapSemantic and MapSemanticPos, or with Map from an object with
+// a native type to an object with a UAST type. Types are resolved from string
+// literals or string constants of the package given in consts.
+// It updates given driverStats with results.
+func findMappings(f *ast.File, consts map[string]string, driver *driverStats) {
+	uastName := importName(f, uastPkg)
+	if uastName == "" {
+		return
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+		var native, typ string
+		switch funcName(call.Fun) {
+		case "MapSemantic", "MapSemanticPos":
+			native, ok = stringValue(call.Args[0], consts)
+			if !ok {
+				return true
+			}
+			typ, ok = semanticType(call.Args[1], uastName)
+		case "Map":
+			native, ok = objectType(call.Args[0], uastName, consts)
+			if !ok || strings.HasPrefix(native, uast.NS+":") {
+				return true
+			}
+			typ, ok = objectType(call.Args[1], uastName, consts)
+			ok = ok && strings.HasPrefix(typ, uast.NS+":")
+		default:
+			return true
+		}
+		if !ok {
+			return true
+		}
+		for _, t := range driver.nativeMappedTo[native] {
+			if t == typ {
+				return true
+			}
+		}
+		driver.nativeMappedTo[native] = append(driver.nativeMappedTo[native], typ)
+		return true
+	})
+}
+
+// funcName returns the name of the called function, either local or qualified.
+func funcName(fnc ast.Expr) string {
+	switch fnc := fnc.(type) {
+	case *ast.Ident:
+		return fnc.Name
+	case *ast.SelectorExpr:
+		return fnc.Sel.Name
+	}
+	return ""
+}
+
+// semanticType returns the UAST type of the semantic node value, like &uast.Identifier{}.
+func semanticType(expr ast.Expr, uastName string) (string, bool) {
+	if u, ok := expr.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		expr = u.X
+	}
+	clit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return "", false
+	}
+	sel, ok := clit.Type.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	if id, ok := sel.X.(*ast.Ident); !ok || id.Name != uastName {
+		return "", false
+	}
+	return uast.NS + ":" + sel.Sel.Name, true
+}
+
+// objectType returns the type of the object transformation, that is a value
+// of the uast.KeyType field of Obj{uast.KeyType: String("type")}, possibly
+// wrapped into Part.
+func objectType(expr ast.Expr, uastName string, consts map[string]string) (string, bool) {
+	if call, ok := expr.(*ast.CallExpr); ok && funcName(call.Fun) == "Part" && len(call.Args) == 2 {
+		expr = call.Args[1]
+	}
+	clit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return "", false
+	}
+	for _, elt := range clit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok || !isTypeKey(kv.Key, uastName, consts) {
+			continue
+		}
+		call, ok := kv.Value.(*ast.CallExpr)
+		if !ok || funcName(call.Fun) != "String" || len(call.Args) != 1 {
+			return "", false
+		}
+		return stringValue(call.Args[0], consts)
+	}
+	return "", false
+}
+
+// isTypeKey checks if the expression is the uast.KeyType object key.
+func isTypeKey(expr ast.Expr, uastName string, consts map[string]string) bool {
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		id, ok := sel.X.(*ast.Ident)
+		return ok && id.Name == uastName && sel.Sel.Name == "KeyType"
+	}
+	key, ok := stringValue(expr, consts)
+	return ok && key == uast.KeyType
+}
+
+// stringValue returns the value of a string literal or of a string constant of the package.
+func stringValue(expr ast.Expr, consts map[string]string) (string, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		if expr.Kind != token.STRING {
+			return "", false
+		}
+		v, err := strconv.Unquote(expr.Value)
+		return v, err == nil
+	case *ast.Ident:
+		v, ok := consts[expr.Name]
+		return v, ok
+	}
+	return "", false
+}
+
+// stringConsts returns values of all package-level constants of the files
+// initialized with a string literal, keyed by the constant name.
+func stringConsts(files []*ast.File) map[string]string {
+	consts := make(map[string]string)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					if v, ok := stringValue(vs.Values[i], nil); ok {
+						consts[name.Name] = v
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+// nativeTypes returns all native types seen in the driver fixtures,
+// most frequent first.
+func nativeTypes(driver *driverStats) []string {
+	types := make([]string, 0, len(driver.nativeInFixtures))
+	for typ := range driver.nativeInFixtures {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ci, cj := driver.nativeInFixtures[types[i]], driver.nativeInFixtures[types[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+func formatNativeMarkdown(w io.Writer, drivers []*driverStats) {
+	fmt.Fprint(w, nativeHeader)
+	defer fmt.Fprint(w, footer)
+
+	drs := withFixtures(drivers)
+	for _, dr := range drs {
+		fmt.Fprintf(w, "\n## %s\n\n", driverLink(dr))
+		fmt.Fprint(w, "| Native type | Fixtures usage | Unmapped usage | Mapped to |\n")
+		fmt.Fprint(w, "| :---------- | :------------- | :------------- | :-------- |\n")
+		for _, typ := range nativeTypes(dr) {
+			unmapped := "-"
+			if v := dr.nativeInSemantic[typ]; v > 0 {
+				unmapped = strconv.Itoa(v)
+			}
+			mapped := "-"
+			if v := dr.nativeMappedTo[typ]; len(v) > 0 {
+				mapped = strings.Join(v, ", ")
+			}
+			fmt.Fprintf(w, "| %s | %d | %s | %s |\n",
+				typ, dr.nativeInFixtures[typ], unmapped, mapped,
+			)
+		}
+	}
+	formatStaleNote(w, drs)
+}
+
+const nativeHeader = `<!-- Code generated by 'make native' DO NOT EDIT. -->
+# Native AST Types
+
+For every native AST type in every driver the following values are reported:
+ - _fixtures usage_ - number of times this type was used in driver _fixtures_ (_*.native_ files)
+ - _unmapped usage_ - number of times this type was left unmapped in driver _fixtures_ (_*.sem.uast_ files)
+ - _mapped to_ - UAST types this type is mapped to in the driver mapping DSL code (_normalizer_ package),
+   either with _MapSemantic_ or with _Map_ between objects with literal types
+
+Types are sorted by _fixtures usage_, so the most common constructs are listed first.
+`
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/nodes"
+	"github.com/bblfsh/sdk/v3/uast/transformer/positioner"
+)
+
+// maxPositionErrors is the max number of position errors listed for each driver.
+const maxPositionErrors = 50
+
+// nodePositions returns start and end positions of the node, if any.
+// Unlike uast.PositionsOf, it returns an error for malformed positions instead of panicking.
+// Positions that cannot be decoded are returned as nil.
+func nodePositions(obj nodes.Object) (start, end *uast.Position, err error) {
+	pos, _ := obj[uast.KeyPos].(nodes.Object)
+	start, err1 := asPosition(pos[uast.KeyStart])
+	end, err2 := asPosition(pos[uast.KeyEnd])
+	if err1 != nil {
+		return start, end, err1
+	}
+	return start, end, err2
+}
+
+// asPosition decodes the position node. It returns nil without an error if there is no node.
+func asPosition(n nodes.Node) (*uast.Position, error) {
+	if n == nil {
+		return nil, nil
+	}
+	obj, ok := n.(nodes.Object)
+	if !ok || uast.TypeOf(obj) != uast.TypePosition {
+		return nil, fmt.Errorf("expected %s object, got %T", uast.TypePosition, n)
+	}
+	var p uast.Position
+	if err := uast.NodeAs(obj, &p); err != nil {
+		return nil, fmt.Errorf("malformed position: %s", err)
+	}
+	return &p, nil
+}
+
+func formatPosition(p *uast.Position) string {
+	return fmt.Sprintf("%d:%d (offset %d)", p.Line, p.Col, p.Offset)
+}
+
+// validatePositions checks that positions in the fixture agree with its source file:
+// offsets and line/col pairs point to the same place inside the file,
+// start of the node is not after its end and nodes are nested inside their parents.
+// It updates given driverStats with results.
+func validatePositions(root nodes.Node, source string, driver *driverStats) {
+	file := filepath.Base(source)
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		driver.positionErrors = append(driver.positionErrors, fmt.Sprintf("%s: %s", file, err))
+		return
+	}
+	idx := positioner.NewIndex(data, &positioner.IndexOptions{Unicode: true})
+
+	report := func(obj nodes.Object, format string, args ...interface{}) {
+		driver.positionErrors = append(driver.positionErrors,
+			fmt.Sprintf("%s: %s: ", file, uast.TypeOf(obj))+fmt.Sprintf(format, args...),
+		)
+	}
+
+	var walk func(n nodes.Node, parentStart, parentEnd *uast.Position)
+	walk = func(n nodes.Node, parentStart, parentEnd *uast.Position) {
+		switch n := n.(type) {
+		case nodes.Array:
+			for _, v := range n {
+				walk(v, parentStart, parentEnd)
+			}
+		case nodes.Object:
+			start, end, err := nodePositions(n)
+			if err != nil {
+				driver.positionsChecked++
+				report(n, "%s", err)
+			}
+			for _, p := range []*uast.Position{start, end} {
+				if p == nil {
+					continue
+				}
+				driver.positionsChecked++
+				if err := checkPosition(idx, len(data), p); err != nil {
+					report(n, "%s", err)
+				}
+			}
+			if start != nil && end != nil {
+				if end.Less(*start) {
+					report(n, "start %s is after end %s", formatPosition(start), formatPosition(end))
+				}
+				if parentStart != nil && parentEnd != nil &&
+					start.HasOffset() && end.HasOffset() && parentStart.HasOffset() && parentEnd.HasOffset() &&
+					(start.Offset < parentStart.Offset || end.Offset > parentEnd.Offset) {
+					report(n, "node [%d, %d] is outside of its parent [%d, %d]",
+						start.Offset, end.Offset, parentStart.Offset, parentEnd.Offset,
+					)
+				}
+				parentStart, parentEnd = start, end
+			}
+			for _, k := range n.Keys() {
+				if k == uast.KeyPos {
+					continue
+				}
+				walk(n[k], parentStart, parentEnd)
+			}
+		}
+	}
+	walk(root, nil, nil)
+}
+
+// checkPosition checks that the position points inside the file,
+// and that its offset and line/col pair agree. Columns are allowed
+// to be counted either in bytes, in Unicode characters or in UTF-16 code units.
+func checkPosition(idx *positioner.Index, size int, p *uast.Position) error {
+	if !p.HasOffset() {
+		if !p.HasLineCol() {
+			return nil
+		}
+		if _, err := idx.Offset(int(p.Line), int(p.Col)); err != nil {
+			return fmt.Errorf("%s is outside of the file: %s", formatPosition(p), err)
+		}
+		return nil
+	}
+	if int(p.Offset) > size {
+		return fmt.Errorf("%s is outside of the file of %d bytes", formatPosition(p), size)
+	}
+	if !p.HasLineCol() {
+		return nil
+	}
+	for _, lineCol := range []func(int) (int, int, error){
+		idx.LineCol, idx.ToUnicodeLineCol, idx.ToUTF16LineCol,
+	} {
+		line, col, err := lineCol(int(p.Offset))
+		if err == nil && line == int(p.Line) && col == int(p.Col) {
+			return nil
+		}
+	}
+	line, col, _ := idx.LineCol(int(p.Offset))
+	return fmt.Errorf("%s does not match the offset, expected %d:%d", formatPosition(p), line, col)
+}
+
+func formatPositionsMarkdown(w io.Writer, drivers []*driverStats) {
+	fmt.Fprint(w, positionsHeader)
+	defer fmt.Fprint(w, footer)
+
+	drs := withFixtures(drivers)
+	fmt.Fprint(w, "| Driver | Positions | Errors |\n")
+	fmt.Fprint(w, "| :----- | :-------- | :----- |\n")
+	for _, dr := range drs {
+		fmt.Fprintf(w, "| %s | %d | %d |\n", driverLink(dr), dr.positionsChecked, len(dr.positionErrors))
+	}
+	formatStaleNote(w, drs)
+
+	for _, dr := range drs {
+		if len(dr.positionErrors) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", driverLink(dr))
+		for i, e := range dr.positionErrors {
+			if i == maxPositionErrors {
+				fmt.Fprintf(w, " - ... and %d more\n", len(dr.positionErrors)-i)
+				break
+			}
+			fmt.Fprintf(w, " - `%s`\n", e)
+		}
+	}
+}
+
+const positionsHeader = `<!-- Code generated by 'make positions' DO NOT EDIT. -->
+# UAST Positions
+
+Positions of nodes in every driver _fixtures_ (_*.sem.uast_ files) are checked against the source files:
+ - the offset and the line/column pair should point to the same place inside the file
+ - the start of the node should not be after its end
+ - the node should be nested inside its parent
+
+`
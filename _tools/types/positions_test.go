@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bblfsh/sdk/v3/uast"
+	"github.com/bblfsh/sdk/v3/uast/transformer/positioner"
+)
+
+func TestCheckPosition(t *testing.T) {
+	// "é" takes 2 bytes, "😀" takes 4 bytes and 2 UTF-16 code units
+	const src = "a := \"é😀x\"\nb\n"
+	idx := positioner.NewIndex([]byte(src), &positioner.IndexOptions{Unicode: true})
+
+	cases := []struct {
+		name string
+		pos  uast.Position
+		ok   bool
+	}{
+		{name: "file start", pos: uast.Position{Offset: 0, Line: 1, Col: 1}, ok: true},
+		{name: "second line", pos: uast.Position{Offset: 15, Line: 2, Col: 1}, ok: true},
+		{name: "byte column", pos: uast.Position{Offset: 12, Line: 1, Col: 13}, ok: true},
+		{name: "unicode column", pos: uast.Position{Offset: 12, Line: 1, Col: 9}, ok: true},
+		{name: "utf16 column", pos: uast.Position{Offset: 12, Line: 1, Col: 10}, ok: true},
+		{name: "column mismatch", pos: uast.Position{Offset: 12, Line: 1, Col: 11}},
+		{name: "line mismatch", pos: uast.Position{Offset: 15, Line: 1, Col: 1}},
+		{name: "offset only", pos: uast.Position{Offset: 12}, ok: true},
+		{name: "line/col only", pos: uast.Position{Line: 2, Col: 1}, ok: true},
+		{name: "line/col outside", pos: uast.Position{Line: 5, Col: 1}},
+		{name: "offset at EOF", pos: uast.Position{Offset: uint32(len(src))}, ok: true},
+		{name: "line/col at EOF", pos: uast.Position{Offset: uint32(len(src)), Line: 3, Col: 1}, ok: true},
+		{name: "offset after EOF", pos: uast.Position{Offset: uint32(len(src)) + 1}},
+		{name: "no position", pos: uast.Position{}, ok: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkPosition(idx, len(src), &c.pos)
+			if c.ok && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if !c.ok && err == nil {
+				t.Fatalf("expected an error for %s", formatPosition(&c.pos))
+			}
+		})
+	}
+}
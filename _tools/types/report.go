@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Driver is a machine-readable report about UAST types usage by a single driver.
+type Driver struct {
+	Language string
+	Name     string
+	URL      string
+	Skipped  bool   `json:",omitempty"` // driver has no fixtures
+	Sync     string // status of the driver repository synchronization
+	Stale    bool   `json:",omitempty"` // driver repository failed to sync
+	Fixtures int
+	Types    map[string]TypeUsage // keyed by UAST type name, e.g. uast:Identifier
+}
+
+// TypeUsage is a number of times UAST type is used in fixtures and in the code of a driver.
+type TypeUsage struct {
+	Fixtures int
+	Code     int
+}
+
+// newReport converts drivers stats to a machine-readable report.
+func newReport(drivers []*driverStats, uastTypes []uastType) []Driver {
+	list := make([]Driver, 0, len(drivers))
+	for _, dr := range drivers {
+		list = append(list, newDriverReport(dr, uastTypes))
+	}
+	return list
+}
+
+// newDriverReport converts stats of a single driver to a machine-readable report.
+func newDriverReport(dr *driverStats, uastTypes []uastType) Driver {
+	d := Driver{
+		Language: dr.language,
+		Name:     dr.name,
+		URL:      dr.url,
+		Skipped:  dr.skip,
+		Sync:     dr.sync.String(),
+		Stale:    dr.sync == syncFailed,
+		Fixtures: dr.fixtureCount,
+		Types:    make(map[string]TypeUsage, len(uastTypes)),
+	}
+	for _, typee := range uastTypes {
+		d.Types[uastName(typee)] = TypeUsage{
+			Fixtures: dr.uastInFixturesCount[typee.name],
+			Code:     dr.uastInCodeCount[typee.name],
+		}
+	}
+	return d
+}
+
+// uastName returns UAST name of the type, e.g. uast:Identifier.
+func uastName(typee uastType) string {
+	return strings.Replace(typee.name, ".", ":", 1)
+}
+
+// writeFile writes the report to a file, choosing the format based on the file extension.
+func writeFile(fname string, drivers []*driverStats, uastTypes []uastType) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	switch filepath.Ext(fname) {
+	case ".json":
+		err = writeJSON(w, drivers, uastTypes)
+	case ".csv":
+		err = writeCSV(w, drivers, uastTypes)
+	case ".md":
+		fallthrough
+	default:
+		err = writeMarkdown(w, drivers, uastTypes)
+	}
+	if err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeMarkdown(w io.Writer, drivers []*driverStats, uastTypes []uastType) error {
+	switch *report {
+	case "types":
+		formatMarkdownTable(w, drivers, uastTypes)
+	case "fields":
+		formatFieldsMarkdown(w, drivers, uastTypes)
+	case "native":
+		formatNativeMarkdown(w, drivers)
+	case "examples":
+		formatExamplesMarkdown(w, drivers, uastTypes)
+	case "positions":
+		formatPositionsMarkdown(w, drivers)
+	default:
+		return fmt.Errorf("unknown report: %q", *report)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, drivers []*driverStats, uastTypes []uastType) error {
+	data, err := json.MarshalIndent(newReport(drivers, uastTypes), "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+var csvHeader = []string{"language", "name", "skipped", "sync", "fixtures", "type", "fixtures_usage", "code_usage"}
+
+func writeCSV(w io.Writer, drivers []*driverStats, uastTypes []uastType) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, d := range newReport(drivers, uastTypes) {
+		for _, typee := range uastTypes {
+			name := uastName(typee)
+			u := d.Types[name]
+			err := cw.Write([]string{
+				d.Language, d.Name,
+				strconv.FormatBool(d.Skipped),
+				d.Sync,
+				strconv.Itoa(d.Fixtures),
+				name,
+				strconv.Itoa(u.Fixtures),
+				strconv.Itoa(u.Code),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}